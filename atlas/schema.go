@@ -0,0 +1,44 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atlas
+
+// Schema holds the statements creating the tables of the atlas database if they don't exist.
+// The importer drops geo_location beforehand, it is rebuilt on every import.
+var Schema = []string{
+	`CREATE TABLE IF NOT EXISTS geo_location (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		country_code TEXT,
+		postal_code TEXT,
+		place_name TEXT,
+		admin_name1 TEXT,
+		admin_code1 TEXT,
+		admin_name2 TEXT,
+		admin_code2 TEXT,
+		admin_name3 TEXT,
+		admin_code3 TEXT,
+		latitude REAL,
+		longitude REAL,
+		accuracy INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+// Indexes holds the statements creating the indexes backing the lookups of the package if they don't exist.
+// The importer creates them once the data is loaded, keeping them up to date during the load would slow it down.
+var Indexes = []string{
+	// GetGeoLocationByPostalCode
+	`CREATE INDEX IF NOT EXISTS geo_location_postal_code_idx ON geo_location (postal_code)`,
+}
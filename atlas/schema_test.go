@@ -0,0 +1,64 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atlas
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexes(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Every connection to an in-memory database opens a database of its own
+	db.SetMaxOpenConns(1)
+	for _, query := range Schema {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	for _, query := range Indexes {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+getGeoLocationByPostalCodeQuery, "110001")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var (
+			id, parent, notUsed int
+			detail              string
+		)
+		require.NoError(t, rows.Scan(&id, &parent, &notUsed, &detail))
+		plan = append(plan, detail)
+	}
+	require.NoError(t, rows.Err())
+
+	// A SCAN reads every row of the table, the lookup is expected to SEARCH the postal code index instead
+	require.NotEmpty(t, plan)
+	for _, detail := range plan {
+		assert.False(t, strings.HasPrefix(detail, "SCAN"), "full scan in %q", plan)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// addressSeparator matches the separators between the parts of an address
+	addressSeparator = regexp.MustCompile(`[,;\n]+`)
+	// pinLabel matches a dangling pin code label left at the end of a part once the pin code is removed
+	pinLabel = regexp.MustCompile(`(?i)(^|[^A-Z])(PIN ?CODE|PIN|PC)[\s.:\-]*$`)
+	// districtLabel matches the district label written before or after a district name
+	districtLabel = regexp.MustCompile(`(?i)^(DISTRICT|DIST|DT)[\s.:\-]+|[\s.:\-]+(DISTRICT|DIST|DT)\.?$`)
+	// spaces matches runs of whitespace
+	spaces = regexp.MustCompile(`\s+`)
+)
+
+// upperWords are the abbreviations kept upper cased in a formatted address
+var upperWords = map[string]bool{
+	"APMC": true, "BO": true, "CBD": true, "GPO": true, "HO": true, "ITI": true, "LIC": true,
+	"MG": true, "MIDC": true, "NH": true, "PO": true, "RO": true, "SH": true,
+}
+
+// lowerWords are the words kept lower cased in a formatted address unless they start it
+var lowerWords = map[string]bool{
+	"AND": true, "OF": true, "THE": true,
+}
+
+// BranchAddress represents the address of a bank branch split into its parts
+type BranchAddress struct {
+	// Line specifies the building and street of the branch
+	Line string `json:"line"`
+	// Locality specifies the locality or area the branch is in
+	Locality string `json:"locality"`
+	// City specifies the city the branch is in
+	City string `json:"city"`
+	// District specifies the district the branch is in
+	District string `json:"district"`
+	// State specifies the state the branch is in
+	State string `json:"state"`
+	// PIN specifies the pin code of the branch
+	PIN string `json:"pin"`
+}
+
+// ParseAddress splits the raw address of a branch into its parts, formatted in title case.
+// The city, district and state of the branch are used as is for the matching parts,
+// and are removed from the address when it repeats them.
+func ParseAddress(address, city, district, state string) BranchAddress {
+	parsed := BranchAddress{
+		City:     formatAddressPart(city),
+		District: formatAddressPart(district),
+		State:    formatAddressPart(state),
+		PIN:      ExtractPIN(address),
+	}
+	if parsed.City == "" {
+		parsed.City = parsed.District
+	}
+
+	// Remove the pin code so that it is not left in the locality
+	if matches := pinPattern.FindAllStringSubmatchIndex(address, -1); len(matches) > 0 {
+		last := matches[len(matches)-1]
+		address = address[:last[2]] + "," + address[last[5]:]
+	}
+
+	known := map[string]bool{"INDIA": true}
+	for _, name := range []string{city, district, state} {
+		if name = normalizeAddressPart(name); name != "" {
+			known[name] = true
+		}
+	}
+
+	var parts []string
+	for _, part := range addressSeparator.Split(address, -1) {
+		part = strings.Trim(pinLabel.ReplaceAllString(cleanAddressPart(part), "$1"), " .:-/")
+		if part == "" || known[normalizeAddressPart(part)] || known[normalizeAddressPart(districtLabel.ReplaceAllString(part, ""))] {
+			continue
+		}
+		if len(parts) > 0 && strings.EqualFold(parts[len(parts)-1], part) {
+			continue
+		}
+		parts = append(parts, part)
+	}
+
+	switch len(parts) {
+	case 0:
+	case 1:
+		parsed.Line = formatAddressPart(parts[0])
+	default:
+		parsed.Line = formatAddressPart(strings.Join(parts[:len(parts)-1], ", "))
+		parsed.Locality = formatAddressPart(parts[len(parts)-1])
+	}
+
+	return parsed
+}
+
+// String returns the address on a single line, e.g. "Kamal Nath Marg, Kurla-East, Mumbai, Maharashtra - 400024".
+// The district is left out when it is the same as the city.
+func (a BranchAddress) String() string {
+	var parts []string
+	for _, part := range []string{a.Line, a.Locality, a.City, a.District, a.State} {
+		if part != "" && (len(parts) == 0 || parts[len(parts)-1] != part) {
+			parts = append(parts, part)
+		}
+	}
+
+	formatted := strings.Join(parts, ", ")
+	if a.PIN != "" {
+		formatted += " - " + a.PIN
+	}
+
+	return formatted
+}
+
+// cleanAddressPart collapses the whitespace of a part of an address and trims the punctuation around it
+func cleanAddressPart(part string) string {
+	return strings.Trim(spaces.ReplaceAllString(part, " "), " .:-/")
+}
+
+// normalizeAddressPart upper cases a part of an address and strips everything but its letters and digits
+func normalizeAddressPart(part string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+
+		return -1
+	}, part)
+}
+
+// formatAddressPart returns a part of an address in title case, e.g. "1st Floor, M.G. Road" for "1ST FLOOR,M.G. ROAD"
+func formatAddressPart(part string) string {
+	part = cleanAddressPart(part)
+	part = strings.ReplaceAll(part, ",", ", ")
+	words := strings.Fields(part)
+	for i, word := range words {
+		key := normalizeAddressPart(word)
+		switch {
+		case upperWords[key]:
+			words[i] = strings.ToUpper(word)
+		case lowerWords[key] && i > 0:
+			words[i] = strings.ToLower(word)
+		default:
+			words[i] = titleWord(word)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// titleWord upper cases the letters of a word that follow a punctuation mark and lower cases the rest,
+// so that "KURLA-EAST" becomes "Kurla-East" and "1ST" becomes "1st"
+func titleWord(word string) string {
+	runes := []rune(word)
+	for i, r := range runes {
+		if i == 0 || !(unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1]) || runes[i-1] == '\'') {
+			runes[i] = unicode.ToUpper(r)
+		} else {
+			runes[i] = unicode.ToLower(r)
+		}
+	}
+
+	return string(runes)
+}
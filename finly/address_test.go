@@ -0,0 +1,92 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	testCases := []struct {
+		name           string
+		address        string
+		city           string
+		district       string
+		state          string
+		expectedOutput BranchAddress
+		expectedString string
+	}{
+		{
+			name:     "city and pin at the end",
+			address:  "ABHYUDAYA BUILDING, KAMAL NATH MARG,NEHRU NAGAR,KURLA-EAST,MUMBAI-400024",
+			city:     "MUMBAI",
+			district: "MUMBAI",
+			state:    "MAHARASHTRA",
+			expectedOutput: BranchAddress{
+				Line:     "Abhyudaya Building, Kamal Nath Marg, Nehru Nagar",
+				Locality: "Kurla-East",
+				City:     "Mumbai",
+				District: "Mumbai",
+				State:    "Maharashtra",
+				PIN:      "400024",
+			},
+			expectedString: "Abhyudaya Building, Kamal Nath Marg, Nehru Nagar, Kurla-East, Mumbai, Maharashtra - 400024",
+		},
+		{
+			name:     "district label, state and pin label",
+			address:  "1ST FLOOR,  M.G. ROAD; OPP BUS STAND, GHATKOPAR, DIST. THANE, MAHARASHTRA, PIN CODE: 400 077, INDIA",
+			city:     "GHATKOPAR",
+			district: "THANE",
+			state:    "MAHARASHTRA",
+			expectedOutput: BranchAddress{
+				Line:     "1st Floor, M.G. Road",
+				Locality: "Opp Bus Stand",
+				City:     "Ghatkopar",
+				District: "Thane",
+				State:    "Maharashtra",
+				PIN:      "400077",
+			},
+			expectedString: "1st Floor, M.G. Road, Opp Bus Stand, Ghatkopar, Thane, Maharashtra - 400077",
+		},
+		{
+			name:     "single part without a pin",
+			address:  "main road",
+			district: "DADRA AND NAGAR HAVELI",
+			state:    "DADRA AND NAGAR HAVELI AND DAMAN AND DIU",
+			expectedOutput: BranchAddress{
+				Line:     "Main Road",
+				City:     "Dadra and Nagar Haveli",
+				District: "Dadra and Nagar Haveli",
+				State:    "Dadra and Nagar Haveli and Daman and Diu",
+			},
+			expectedString: "Main Road, Dadra and Nagar Haveli, Dadra and Nagar Haveli and Daman and Diu",
+		},
+		{
+			name:           "empty address",
+			expectedOutput: BranchAddress{},
+			expectedString: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			address := ParseAddress(tc.address, tc.city, tc.district, tc.state)
+			assert.Equal(t, tc.expectedOutput, address)
+			assert.Equal(t, tc.expectedString, address.String())
+		})
+	}
+}
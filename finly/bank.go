@@ -18,21 +18,57 @@ package finly
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 
 	_ "github.com/libsql/libsql-client-go/libsql" // Import the libsql driver
 	_ "modernc.org/sqlite"                        // Import the sqlite driver
 )
 
+// upstreamColumns is the list of bank columns imported as is from the razorpay dataset.
+// The optional columns are null when the release lacks them.
+const upstreamColumns = `name, code, ifsc, branch, COALESCE(center, ''),
+district, state, address, COALESCE(contact, ''),
+imps, rtgs, city, COALESCE(iso3166, ''),
+neft, COALESCE(micr, ''), upi, COALESCE(swift, '')`
+
+// bankColumns is the list of bank columns selected by every bank query, in the order scanned by scanBank.
+// It follows the upstream columns with the columns derived at import time.
+const bankColumns = upstreamColumns + `,
+COALESCE(pin, ''), COALESCE(latitude, 0), COALESCE(longitude, 0), COALESCE(geo_confidence, 'none'),
+COALESCE(address_line, ''), COALESCE(address_locality, ''), COALESCE(address_city, ''),
+COALESCE(address_district, ''), COALESCE(address_state, ''), COALESCE(phones, ''),
+COALESCE(sponsor_code, ''), COALESCE((SELECT name FROM bank_master WHERE bank_master.code = bank.sponsor_code), '')`
+
+// derivedColumns are the bank columns derived at import time, in the order of bankColumns, along with the value
+// selected in their place from a database built by an older importer that lacks them
+var derivedColumns = []struct {
+	name     string
+	fallback string
+}{
+	{name: "pin", fallback: "''"},
+	{name: "latitude", fallback: "0"},
+	{name: "longitude", fallback: "0"},
+	{name: "geo_confidence", fallback: "'none'"},
+	{name: "address_line", fallback: "''"},
+	{name: "address_locality", fallback: "''"},
+	{name: "address_city", fallback: "''"},
+	{name: "address_district", fallback: "''"},
+	{name: "address_state", fallback: "''"},
+	{name: "phones", fallback: "''"},
+	{name: "sponsor_code", fallback: "''"},
+}
+
 // getBankByIFSCQuery is the query to get the bank by ifsc
-const getBankByIFSCQuery = `SELECT name, code, ifsc, branch, center,
-district, state, address, contact,
-imps, rtgs, city, iso3166,
-neft, micr, upi, swift
+const getBankByIFSCQuery = `SELECT ` + bankColumns + `
 FROM bank WHERE ifsc = ?`
 
+// bankCodeLength is the length of the bank code, which is also the prefix of every ifsc of the bank
+const bankCodeLength = 4
+
 var ErrBankNotFound = errors.New("bank not found")
 
 // Bank entity represents the bank across the indian banking system
@@ -47,7 +83,8 @@ type Bank struct {
 	Micr string `json:"micr"`
 	// Branch specifies the branch of the bank
 	Branch string `json:"branch"`
-	// Code specifies the code of the bank which is unique and 4 letters long
+	// Code specifies the code of the bank which is unique and 4 letters long.
+	// It is the code of the actual institution, which differs from the ifsc prefix for sub-member banks.
 	Code string `json:"code"`
 	// Contact information of the bank
 	Contact string `json:"contact"`
@@ -71,14 +108,37 @@ type Bank struct {
 	Imps bool `json:"imps"`
 	// Upi specifies whether the bank supports upi
 	Upi bool `json:"upi"`
+	// Pin specifies the pin code found in the address of the bank
+	Pin string `json:"pin"`
+	// Latitude specifies the latitude of the bank
+	Latitude float64 `json:"latitude"`
+	// Longitude specifies the longitude of the bank
+	Longitude float64 `json:"longitude"`
+	// GeoConfidence specifies how reliable the latitude and longitude of the bank are
+	GeoConfidence GeoConfidence `json:"geo_confidence"`
+	// StructuredAddress specifies the address of the bank split into its parts
+	StructuredAddress BranchAddress `json:"structured_address"`
+	// Phones specifies the phone numbers found in the contact of the bank
+	Phones []PhoneNumber `json:"phones"`
+	// SponsorCode specifies the code of the sponsor bank a sub-member bank clears through, empty otherwise
+	SponsorCode string `json:"sponsor_code,omitempty"`
+	// SponsorName specifies the name of the sponsor bank a sub-member bank clears through, empty otherwise
+	SponsorName string `json:"sponsor_name,omitempty"`
 }
 
 // Finly is the store that interacts with the database
 type Finly struct {
 	store *sql.DB
+	// columns selects the bank columns in place of bankColumns from a database built by an older importer,
+	// it is empty if the database has every column
+	columns string
+	// missing are the derived bank columns the database lacks
+	missing map[string]bool
 }
 
 // New returns a new finly instance that interacts with the database.
+// A database built by an older importer lacks the columns derived at import time, the address and the phone
+// numbers of its branches are then parsed on every lookup and its branches are not geocoded.
 // It returns an error if it fails to open the database.
 func New() (*Finly, error) {
 	db, err := sql.Open("libsql", "file://"+getDBPath()+"?mode=ro")
@@ -86,7 +146,88 @@ func New() (*Finly, error) {
 		return nil, err
 	}
 
-	return &Finly{store: db}, nil
+	b := &Finly{store: db}
+	err = b.detectColumns(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// detectColumns looks up the derived bank columns the database lacks and the columns selected in their place
+func (b *Finly) detectColumns(ctx context.Context) error {
+	rows, err := b.store.QueryContext(ctx, `SELECT name FROM pragma_table_info('bank')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	present := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return err
+		}
+		present[name] = true
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	var hasBankMaster bool
+	err = b.store.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'bank_master')`).
+		Scan(&hasBankMaster)
+	if err != nil {
+		return err
+	}
+
+	b.missing = make(map[string]bool)
+	columns := make([]string, 0, len(derivedColumns)+1)
+	for _, c := range derivedColumns {
+		if present[c.name] {
+			columns = append(columns, "COALESCE("+c.name+", "+c.fallback+")")
+		} else {
+			b.missing[c.name] = true
+			columns = append(columns, c.fallback)
+		}
+	}
+
+	sponsorName := `COALESCE((SELECT name FROM bank_master WHERE bank_master.code = bank.sponsor_code), '')`
+	if !present["sponsor_code"] || !hasBankMaster {
+		sponsorName = "''"
+	}
+
+	if len(b.missing) > 0 || !hasBankMaster {
+		b.columns = upstreamColumns + ",\n" + strings.Join(append(columns, sponsorName), ", ")
+	}
+
+	return nil
+}
+
+// bankQuery returns the query selecting bankColumns adapted to the columns of the database
+func (b *Finly) bankQuery(query string) string {
+	if b.columns == "" {
+		return query
+	}
+
+	return strings.Replace(query, bankColumns, b.columns, 1)
+}
+
+// completeBank parses the address and the contact of the bank if the database lacks the columns derived from them
+func (b *Finly) completeBank(bank *Bank) {
+	if b.missing["address_line"] || b.missing["phones"] {
+		parseBankFields(bank)
+	}
+}
+
+// parseBankFields parses the address and the contact of the bank into the fields derived from them at import time
+func parseBankFields(bank *Bank) {
+	bank.StructuredAddress = ParseAddress(bank.Address, bank.City, bank.District, bank.State)
+	bank.Pin = bank.StructuredAddress.PIN
+	bank.Phones = ParseContact(bank.Contact)
 }
 
 func findProjectRoot() (string, error) {
@@ -115,21 +256,68 @@ func findProjectRoot() (string, error) {
 }
 
 func getDBPath() string {
+	return getDataPath("finly.db")
+}
+
+func getDataPath(name string) string {
 	// Get the absolute path to the current working directory
 	wd, err := findProjectRoot()
 	if err != nil {
 		panic(err)
 	}
 
-	// Join the current working directory path with the path to the database file relative to the finly package
-	return filepath.Join(wd, "finly", "data", "finly.db")
+	// Join the current working directory path with the path to the data file relative to the finly package
+	return filepath.Join(wd, "finly", "data", name)
+}
+
+// LookupOption configures how GetBankByIFSC looks up a bank
+type LookupOption func(*lookupOptions)
+
+type lookupOptions struct {
+	followRedirect bool
 }
 
 // GetBankByIFSC returns a Bank instance by its IFSC code.
 // It returns an error if it fails to query the database.
-func (b *Finly) GetBankByIFSC(ctx context.Context, ifsc string) (*Bank, error) {
-	var i Bank
-	err := b.store.QueryRowContext(ctx, getBankByIFSCQuery, ifsc).Scan(
+func (b *Finly) GetBankByIFSC(ctx context.Context, ifsc string, opts ...LookupOption) (*Bank, error) {
+	var o lookupOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.followRedirect {
+		var err error
+		ifsc, err = b.resolveRedirect(ctx, ifsc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bank, err := scanBank(b.store.QueryRowContext(ctx, b.bankQuery(getBankByIFSCQuery), ifsc))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBankNotFound
+		}
+
+		return nil, err
+	}
+	b.completeBank(bank)
+
+	return bank, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanBank scans a row selected with bankColumns into a Bank
+func scanBank(row rowScanner) (*Bank, error) {
+	var (
+		i      Bank
+		phones string
+	)
+	err := row.Scan(
 		&i.Name,
 		&i.Code,
 		&i.Ifsc,
@@ -147,14 +335,60 @@ func (b *Finly) GetBankByIFSC(ctx context.Context, ifsc string) (*Bank, error) {
 		&i.Micr,
 		&i.Upi,
 		&i.Swift,
+		&i.Pin,
+		&i.Latitude,
+		&i.Longitude,
+		&i.GeoConfidence,
+		&i.StructuredAddress.Line,
+		&i.StructuredAddress.Locality,
+		&i.StructuredAddress.City,
+		&i.StructuredAddress.District,
+		&i.StructuredAddress.State,
+		&phones,
+		&i.SponsorCode,
+		&i.SponsorName,
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrBankNotFound
+		return nil, err
+	}
+	i.StructuredAddress.PIN = i.Pin
+
+	if phones != "" {
+		err = json.Unmarshal([]byte(phones), &i.Phones)
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	return &i, nil
+}
 
+// queryBanks runs a query selecting bankColumns and returns every matching bank.
+// It returns ErrBankNotFound if the query matches no rows.
+func (b *Finly) queryBanks(ctx context.Context, query string, args ...any) ([]*Bank, error) {
+	rows, err := b.store.QueryContext(ctx, b.bankQuery(query), args...)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &i, nil
+	var banks []*Bank
+	for rows.Next() {
+		var bank *Bank
+		bank, err = scanBank(rows)
+		if err != nil {
+			return nil, err
+		}
+		b.completeBank(bank)
+		banks = append(banks, bank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(banks) == 0 {
+		return nil, ErrBankNotFound
+	}
+
+	return banks, nil
 }
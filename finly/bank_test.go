@@ -17,6 +17,8 @@ package finly
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -24,6 +26,93 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// bankRowColumns are the columns returned by the queries selecting bankColumns
+var bankRowColumns = []string{
+	"name",
+	"code",
+	"ifsc",
+	"branch",
+	"center",
+	"district",
+	"state",
+	"address",
+	"contact",
+	"imps",
+	"rtgs",
+	"city",
+	"iso3166",
+	"neft",
+	"micr",
+	"upi",
+	"swift",
+	"pin",
+	"latitude",
+	"longitude",
+	"geo_confidence",
+	"address_line",
+	"address_locality",
+	"address_city",
+	"address_district",
+	"address_state",
+	"phones",
+	"sponsor_code",
+	"sponsor_name",
+}
+
+// phoneColumn returns the phones column of the given phone numbers
+func phoneColumn(phones []PhoneNumber) string {
+	if len(phones) == 0 {
+		return ""
+	}
+
+	column, err := json.Marshal(phones)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(column)
+}
+
+// bankRows returns the mocked rows of a query selecting bankColumns for the given banks
+func bankRows(banks ...*Bank) *sqlmock.Rows {
+	rows := sqlmock.NewRows(bankRowColumns)
+	for _, b := range banks {
+		rows.AddRow(
+			b.Name,
+			b.Code,
+			b.Ifsc,
+			b.Branch,
+			b.Center,
+			b.District,
+			b.State,
+			b.Address,
+			b.Contact,
+			b.Imps,
+			b.Rtgs,
+			b.City,
+			b.Iso3166,
+			b.Neft,
+			b.Micr,
+			b.Upi,
+			b.Swift,
+			b.Pin,
+			b.Latitude,
+			b.Longitude,
+			b.GeoConfidence,
+			b.StructuredAddress.Line,
+			b.StructuredAddress.Locality,
+			b.StructuredAddress.City,
+			b.StructuredAddress.District,
+			b.StructuredAddress.State,
+			phoneColumn(b.Phones),
+			b.SponsorCode,
+			b.SponsorName,
+		)
+	}
+
+	return rows
+}
+
 func TestGetBankByIFSC(t *testing.T) {
 	ctx := context.Background()
 	testCases := []struct {
@@ -38,25 +127,7 @@ func TestGetBankByIFSC(t *testing.T) {
 			ifsc:          "ABHY0065001",
 			expectedError: error(nil),
 			mockDB: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(getBankByIFSCQuery).WithArgs("ABHY0065001").WillReturnRows(sqlmock.NewRows([]string{
-					"name",
-					"code",
-					"ifsc",
-					"branch",
-					"center",
-					"district",
-					"state",
-					"address",
-					"contact",
-					"imps",
-					"rtgs",
-					"city",
-					"iso3166",
-					"neft",
-					"micr",
-					"upi",
-					"swift",
-				}).AddRow(
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("ABHY0065001").WillReturnRows(sqlmock.NewRows(bankRowColumns).AddRow(
 					"Abhyudaya Co-operative Bank",
 					"ABHY",
 					"ABHY0065001",
@@ -74,33 +145,58 @@ func TestGetBankByIFSC(t *testing.T) {
 					"400065001",
 					"1",
 					"",
+					"400024",
+					19.0728,
+					72.8826,
+					"high",
+					"Abhyudaya Building, Kamal Nath Marg, Nehru Nagar",
+					"Kurla-East",
+					"Mumbai",
+					"Mumbai",
+					"Maharashtra",
+					`[{"number":"+919653261383","type":"mobile"}]`,
+					"",
+					"",
 				))
 			},
 			expectedOutput: &Bank{
-				Name:     "Abhyudaya Co-operative Bank",
-				State:    "MAHARASHTRA",
-				City:     "MUMBAI",
-				Micr:     "400065001",
-				Branch:   "Abhyudaya Co-operative Bank IMPS",
-				Code:     "ABHY",
-				Contact:  "+919653261383",
-				Ifsc:     "ABHY0065001",
-				District: "MUMBAI",
-				Address:  "ABHYUDAYA BUILDING, KAMAL NATH MARG,NEHRU NAGAR,KURLA-EAST,MUMBAI-400024",
-				Center:   "MUMBAI",
-				Swift:    "",
-				Iso3166:  "IN-MH",
-				Neft:     true,
-				Rtgs:     true,
-				Imps:     true,
-				Upi:      true,
+				Name:          "Abhyudaya Co-operative Bank",
+				State:         "MAHARASHTRA",
+				City:          "MUMBAI",
+				Micr:          "400065001",
+				Branch:        "Abhyudaya Co-operative Bank IMPS",
+				Code:          "ABHY",
+				Contact:       "+919653261383",
+				Ifsc:          "ABHY0065001",
+				District:      "MUMBAI",
+				Address:       "ABHYUDAYA BUILDING, KAMAL NATH MARG,NEHRU NAGAR,KURLA-EAST,MUMBAI-400024",
+				Center:        "MUMBAI",
+				Swift:         "",
+				Iso3166:       "IN-MH",
+				Neft:          true,
+				Rtgs:          true,
+				Imps:          true,
+				Upi:           true,
+				Pin:           "400024",
+				Latitude:      19.0728,
+				Longitude:     72.8826,
+				GeoConfidence: GeoConfidenceHigh,
+				StructuredAddress: BranchAddress{
+					Line:     "Abhyudaya Building, Kamal Nath Marg, Nehru Nagar",
+					Locality: "Kurla-East",
+					City:     "Mumbai",
+					District: "Mumbai",
+					State:    "Maharashtra",
+					PIN:      "400024",
+				},
+				Phones: []PhoneNumber{{Number: "+919653261383", Type: PhoneMobile}},
 			},
 		},
 		{
 			name: "invalid ifsc",
 			ifsc: "ABHY0069999",
 			mockDB: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(getBankByIFSCQuery).WithArgs("ABHY0069999").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("ABHY0069999").WillReturnError(sql.ErrNoRows)
 			},
 			expectedError: ErrBankNotFound,
 		},
@@ -130,3 +226,106 @@ func TestGetBankByIFSC(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBankByIFSCWithoutOptionalColumns(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Every connection to an in-memory database opens a database of its own
+	db.SetMaxOpenConns(1)
+	for _, query := range Schema {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	// The importer leaves the optional columns the release lacks null, along with the columns it could not derive
+	_, err = db.ExecContext(ctx, `INSERT INTO bank (name, code, ifsc, branch, district, state, address, imps, rtgs, city, neft, upi)
+	VALUES ('State Bank of India', 'SBIN', 'SBIN0000001', 'KOLKATA MAIN', 'KOLKATA', 'WEST BENGAL', 'SAMRIDDHI BHAWAN',
+	1, 1, 'KOLKATA', 1, 0)`)
+	require.NoError(t, err)
+
+	finly := &Finly{
+		store: db,
+	}
+	require.NoError(t, finly.detectColumns(ctx))
+	assert.Empty(t, finly.columns)
+	assert.Empty(t, finly.missing)
+
+	bank, err := finly.GetBankByIFSC(ctx, "SBIN0000001")
+	require.NoError(t, err)
+	assert.EqualValues(t, &Bank{
+		Name:          "State Bank of India",
+		Code:          "SBIN",
+		Ifsc:          "SBIN0000001",
+		Branch:        "KOLKATA MAIN",
+		District:      "KOLKATA",
+		State:         "WEST BENGAL",
+		Address:       "SAMRIDDHI BHAWAN",
+		City:          "KOLKATA",
+		Imps:          true,
+		Rtgs:          true,
+		Neft:          true,
+		GeoConfidence: GeoConfidenceNone,
+	}, bank)
+}
+
+func TestGetBankByIFSCOlderDatabase(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Every connection to an in-memory database opens a database of its own
+	db.SetMaxOpenConns(1)
+
+	// The bank table of the databases built before the columns derived at import time
+	_, err = db.ExecContext(ctx, `CREATE TABLE bank (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT, code TEXT, ifsc TEXT UNIQUE, branch TEXT, center TEXT, district TEXT, state TEXT, address TEXT,
+		contact TEXT, imps BOOLEAN, rtgs BOOLEAN, city TEXT, iso3166 TEXT, neft BOOLEAN, micr TEXT, upi BOOLEAN, swift TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO bank (name, code, ifsc, branch, center, district, state, address, contact,
+		imps, rtgs, city, iso3166, neft, micr, upi, swift)
+	VALUES ('Abhyudaya Co-operative Bank', 'ABHY', 'ABHY0065001', 'Abhyudaya Co-operative Bank IMPS', 'MUMBAI', 'MUMBAI',
+		'MAHARASHTRA', 'ABHYUDAYA BUILDING, KAMAL NATH MARG,NEHRU NAGAR,KURLA-EAST,MUMBAI-400024', '+919653261383',
+		1, 1, 'MUMBAI', 'IN-MH', 1, '400065001', 1, '')`)
+	require.NoError(t, err)
+
+	finly := &Finly{
+		store: db,
+	}
+	require.NoError(t, finly.detectColumns(ctx))
+	assert.Len(t, finly.missing, len(derivedColumns))
+
+	// The fields derived from the address and the contact are parsed on lookup
+	bank, err := finly.GetBankByIFSC(ctx, "ABHY0065001")
+	require.NoError(t, err)
+	assert.Equal(t, "400024", bank.Pin)
+	assert.Equal(t, BranchAddress{
+		Line:     "Abhyudaya Building, Kamal Nath Marg, Nehru Nagar",
+		Locality: "Kurla-East",
+		City:     "Mumbai",
+		District: "Mumbai",
+		State:    "Maharashtra",
+		PIN:      "400024",
+	}, bank.StructuredAddress)
+	assert.Equal(t, []PhoneNumber{{Number: "+919653261383", Type: PhoneMobile}}, bank.Phones)
+	assert.Equal(t, GeoConfidenceNone, bank.GeoConfidence)
+
+	banks, err := finly.GetBranchesByMICR(ctx, "400065001")
+	require.NoError(t, err)
+	assert.Equal(t, []*Bank{bank}, banks)
+
+	// The branches are neither geocoded nor linked to their sponsor bank
+	nearby, err := finly.NearestBranches(ctx, 19.07, 72.88, BranchFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, nearby)
+
+	_, err = finly.GetBranchesBySponsor(ctx, "ABHY")
+	assert.ErrorIs(t, err, ErrBankNotFound)
+}
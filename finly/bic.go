@@ -0,0 +1,137 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// getBanksBySWIFTQuery is the query to get the bank branches by swift code.
+// It matches either of the two given forms of the same BIC.
+const getBanksBySWIFTQuery = `SELECT ` + bankColumns + `
+FROM bank WHERE swift IN (?, ?) ORDER BY ifsc`
+
+const (
+	// bic8Length is the length of a BIC without the branch code
+	bic8Length = 8
+	// bic11Length is the length of a BIC with the branch code
+	bic11Length = 11
+	// primaryOfficeBranch is the branch code used for the primary office of an institution
+	primaryOfficeBranch = "XXX"
+)
+
+var ErrInvalidBIC = errors.New("invalid bic")
+
+// BIC represents an ISO 9362 business identifier code, also known as the SWIFT code
+type BIC struct {
+	// Institution specifies the 4 character code of the institution
+	Institution string `json:"institution"`
+	// Country specifies the ISO 3166-1 alpha-2 code of the country the institution is in
+	Country string `json:"country"`
+	// Location specifies the 2 character location code of the institution
+	Location string `json:"location"`
+	// Branch specifies the 3 character branch code, XXX for the primary office
+	Branch string `json:"branch"`
+}
+
+// ParseBIC parses and validates an 8 or 11 character BIC.
+// The code is case insensitive and a BIC without a branch code refers to the primary office.
+// It returns ErrInvalidBIC if the code is not a valid ISO 9362 BIC.
+func ParseBIC(code string) (*BIC, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != bic8Length && len(code) != bic11Length {
+		return nil, ErrInvalidBIC
+	}
+
+	bic := &BIC{
+		Institution: code[0:4],
+		Country:     code[4:6],
+		Location:    code[6:8],
+		Branch:      primaryOfficeBranch,
+	}
+	if len(code) == bic11Length {
+		bic.Branch = code[8:11]
+	}
+
+	if !isAlphanumeric(bic.Institution) || !isAlpha(bic.Country) || !isAlphanumeric(bic.Location) || !isAlphanumeric(bic.Branch) {
+		return nil, ErrInvalidBIC
+	}
+
+	return bic, nil
+}
+
+// IsValidBIC reports whether the code is a valid ISO 9362 BIC
+func IsValidBIC(code string) bool {
+	_, err := ParseBIC(code)
+	return err == nil
+}
+
+// String returns the 11 character form of the BIC
+func (b *BIC) String() string {
+	return b.BIC8() + b.Branch
+}
+
+// BIC8 returns the 8 character form of the BIC which identifies the institution's primary office
+func (b *BIC) BIC8() string {
+	return b.Institution + b.Country + b.Location
+}
+
+// IsPrimaryOffice reports whether the BIC refers to the primary office of the institution
+func (b *BIC) IsPrimaryOffice() bool {
+	return b.Branch == primaryOfficeBranch
+}
+
+// IsTest reports whether the BIC is a test and training code, which have 0 as the second location character
+func (b *BIC) IsTest() bool {
+	return b.Location[1] == '0'
+}
+
+// GetBranchesBySWIFT returns the bank branches registered against a SWIFT code (BIC).
+// A primary office BIC matches branches stored with either the 8 or the 11 character form.
+// It returns ErrInvalidBIC if the code is not a valid BIC and ErrBankNotFound if no branch matches.
+func (b *Finly) GetBranchesBySWIFT(ctx context.Context, swift string) ([]*Bank, error) {
+	bic, err := ParseBIC(swift)
+	if err != nil {
+		return nil, err
+	}
+
+	if bic.IsPrimaryOffice() {
+		return b.queryBanks(ctx, getBanksBySWIFTQuery, bic.BIC8(), bic.String())
+	}
+
+	return b.queryBanks(ctx, getBanksBySWIFTQuery, bic.String(), bic.String())
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,222 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBIC(t *testing.T) {
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *BIC
+		name           string
+		code           string
+	}{
+		{
+			name: "bic11",
+			code: "SBININBB104",
+			expectedOutput: &BIC{
+				Institution: "SBIN",
+				Country:     "IN",
+				Location:    "BB",
+				Branch:      "104",
+			},
+		},
+		{
+			name: "bic8 in lower case",
+			code: " hdfcinbb ",
+			expectedOutput: &BIC{
+				Institution: "HDFC",
+				Country:     "IN",
+				Location:    "BB",
+				Branch:      "XXX",
+			},
+		},
+		{
+			name:          "invalid length",
+			code:          "SBININBB10",
+			expectedError: ErrInvalidBIC,
+		},
+		{
+			name:          "numeric country code",
+			code:          "SBIN12BB",
+			expectedError: ErrInvalidBIC,
+		},
+		{
+			name:          "invalid character",
+			code:          "SBIN-NBB",
+			expectedError: ErrInvalidBIC,
+		},
+		{
+			name:          "invalid character in branch code",
+			code:          "SBININBB0_1",
+			expectedError: ErrInvalidBIC,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bic, err := ParseBIC(tc.code)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, bic)
+			}
+		})
+	}
+}
+
+func TestBIC(t *testing.T) {
+	bic, err := ParseBIC("SBININB0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "SBININB0", bic.BIC8())
+	assert.Equal(t, "SBININB0XXX", bic.String())
+	assert.True(t, bic.IsPrimaryOffice())
+	assert.True(t, bic.IsTest())
+	assert.True(t, IsValidBIC("SBININBB104"))
+	assert.False(t, IsValidBIC("SBIN"))
+}
+
+func TestGetBranchesBySWIFT(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput []*Bank
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		swift          string
+	}{
+		{
+			name:  "primary office bic",
+			swift: "SBININBB",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksBySWIFTQuery)).WithArgs("SBININBB", "SBININBBXXX").WillReturnRows(
+					sqlmock.NewRows(bankRowColumns).AddRow(
+						"State Bank of India",
+						"SBIN",
+						"SBIN0000001",
+						"KOLKATA MAIN",
+						"KOLKATA",
+						"KOLKATA",
+						"WEST BENGAL",
+						"SAMRIDDHI BHAVAN, 1 STRAND ROAD, KOLKATA 700 001",
+						"",
+						"1",
+						"1",
+						"KOLKATA",
+						"IN-WB",
+						"1",
+						"700002021",
+						"1",
+						"SBININBBXXX",
+						"700001",
+						22.5697,
+						88.3697,
+						"high",
+						"Samriddhi Bhavan",
+						"1 Strand Road",
+						"Kolkata",
+						"Kolkata",
+						"West Bengal",
+						"",
+						"",
+						"",
+					),
+				)
+			},
+			expectedOutput: []*Bank{
+				{
+					Name:          "State Bank of India",
+					State:         "WEST BENGAL",
+					City:          "KOLKATA",
+					Micr:          "700002021",
+					Branch:        "KOLKATA MAIN",
+					Code:          "SBIN",
+					Ifsc:          "SBIN0000001",
+					District:      "KOLKATA",
+					Address:       "SAMRIDDHI BHAVAN, 1 STRAND ROAD, KOLKATA 700 001",
+					Center:        "KOLKATA",
+					Swift:         "SBININBBXXX",
+					Iso3166:       "IN-WB",
+					Neft:          true,
+					Rtgs:          true,
+					Imps:          true,
+					Upi:           true,
+					Pin:           "700001",
+					Latitude:      22.5697,
+					Longitude:     88.3697,
+					GeoConfidence: GeoConfidenceHigh,
+					StructuredAddress: BranchAddress{
+						Line:     "Samriddhi Bhavan",
+						Locality: "1 Strand Road",
+						City:     "Kolkata",
+						District: "Kolkata",
+						State:    "West Bengal",
+						PIN:      "700001",
+					},
+				},
+			},
+		},
+		{
+			name:  "unknown branch bic",
+			swift: "SBININBB999",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksBySWIFTQuery)).WithArgs("SBININBB999", "SBININBB999").WillReturnRows(
+					sqlmock.NewRows(bankRowColumns),
+				)
+			},
+			expectedError: ErrBankNotFound,
+		},
+		{
+			name:          "invalid bic",
+			swift:         "SBI",
+			mockDB:        func(mock sqlmock.Sqlmock) {},
+			expectedError: ErrInvalidBIC,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			banks, err := finly.GetBranchesBySWIFT(ctx, tc.swift)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, banks)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
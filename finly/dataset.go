@@ -0,0 +1,55 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imumesh18/bifrost/internal/dataset"
+)
+
+var ErrDatasetInfoNotFound = errors.New("dataset info not found")
+
+// DatasetInfo represents the provenance of the dataset served by Finly
+type DatasetInfo struct {
+	// Source specifies the URL the dataset was downloaded from
+	Source string `json:"source"`
+	// Version specifies the upstream version of the dataset, e.g. the razorpay release tag
+	Version string `json:"version"`
+	// ImportedAt specifies when the dataset was imported
+	ImportedAt time.Time `json:"imported_at"`
+	// RowCounts specifies the number of rows imported into every table
+	RowCounts map[string]int64 `json:"row_counts"`
+	// Checksum specifies the checksum of the sources the dataset was built from in the algorithm:hex format
+	Checksum string `json:"checksum"`
+}
+
+// DatasetInfo returns the source, upstream version, import time, row counts and checksum of the served dataset.
+// It returns ErrDatasetInfoNotFound if the dataset was imported without recording its provenance,
+// including by an importer that predates the dataset table.
+func (b *Finly) DatasetInfo(ctx context.Context) (*DatasetInfo, error) {
+	info, err := dataset.Latest(ctx, b.store)
+	if err != nil {
+		if errors.Is(err, dataset.ErrNotFound) {
+			return nil, ErrDatasetInfoNotFound
+		}
+
+		return nil, err
+	}
+
+	return (*DatasetInfo)(info), nil
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasetInfo(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *DatasetInfo
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+	}{
+		{
+			name: "recorded dataset",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM dataset ORDER BY id DESC")).WillReturnRows(
+					sqlmock.NewRows([]string{"source", "version", "checksum", "row_counts", "imported_at"}).AddRow(
+						"https://github.com/razorpay/ifsc/releases/download/v2.0.19/IFSC.csv",
+						"v2.0.19",
+						"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+						`{"bank":177569}`,
+						"2023-10-23T10:15:00Z",
+					),
+				)
+			},
+			expectedOutput: &DatasetInfo{
+				Source:     "https://github.com/razorpay/ifsc/releases/download/v2.0.19/IFSC.csv",
+				Version:    "v2.0.19",
+				ImportedAt: time.Date(2023, time.October, 23, 10, 15, 0, 0, time.UTC),
+				RowCounts:  map[string]int64{"bank": 177569},
+				Checksum:   "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			},
+		},
+		{
+			name: "dataset without provenance",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM dataset ORDER BY id DESC")).WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrDatasetInfoNotFound,
+		},
+		{
+			name: "dataset imported before the dataset table",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM dataset ORDER BY id DESC")).WillReturnError(errors.New("SQL logic error: no such table: dataset (1)"))
+				mock.ExpectQuery(regexp.QuoteMeta("FROM sqlite_master")).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedError: ErrDatasetInfoNotFound,
+		},
+		{
+			name: "query failure",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM dataset ORDER BY id DESC")).WillReturnError(errors.New("database is locked"))
+				mock.ExpectQuery(regexp.QuoteMeta("FROM sqlite_master")).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expectedError: errors.New("database is locked"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			info, err := finly.DatasetInfo(ctx)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, info)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDatasetInfoWithoutDatasetTable(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	finly := &Finly{
+		store: db,
+	}
+
+	_, err = finly.DatasetInfo(context.Background())
+	assert.ErrorIs(t, err, ErrDatasetInfoNotFound)
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+)
+
+// getBanksInBoundsQuery is the query to get the geocoded bank branches within a bounding box
+const getBanksInBoundsQuery = `SELECT ` + bankColumns + `
+FROM bank WHERE latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?`
+
+// getBanksInBoundsByCodeQuery is the query to get the geocoded bank branches of a bank within a bounding box
+const getBanksInBoundsByCodeQuery = `SELECT ` + bankColumns + `
+FROM bank WHERE code = ? AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?`
+
+const (
+	// earthRadius is the mean radius of the earth in kilometers
+	earthRadius = 6371.0
+	// kmPerDegree is the length of a degree of latitude in kilometers
+	kmPerDegree = 111.32
+	// defaultMaxDistance is the default search radius of NearestBranches in kilometers
+	defaultMaxDistance = 25.0
+	// defaultNearestLimit is the default number of branches returned by NearestBranches
+	defaultNearestLimit = 10
+)
+
+// pinPattern matches an indian pin code, which may be written with a space after the first three digits
+var pinPattern = regexp.MustCompile(`(?:^|[^0-9])([1-9][0-9]{2}) ?([0-9]{3})(?:[^0-9]|$)`)
+
+// GeoConfidence specifies how reliable the coordinates of a bank branch are
+type GeoConfidence string
+
+const (
+	// GeoConfidenceHigh means the coordinates are those of the address pin code, which lies in the branch's state
+	GeoConfidenceHigh GeoConfidence = "high"
+	// GeoConfidenceMedium means the coordinates are those of the address pin code, which lies in another state
+	GeoConfidenceMedium GeoConfidence = "medium"
+	// GeoConfidenceLow means the address has no known pin code and the coordinates are those of the branch's district
+	GeoConfidenceLow GeoConfidence = "low"
+	// GeoConfidenceNone means the branch could not be geocoded
+	GeoConfidenceNone GeoConfidence = "none"
+)
+
+// BranchFilter narrows down the branches returned by NearestBranches
+type BranchFilter struct {
+	// Code specifies the code of the bank the branches belong to, any bank if empty
+	Code string
+	// Rails specifies the rails every branch must support
+	Rails []Rail
+	// MaxDistance specifies the search radius in kilometers, 25 km if zero
+	MaxDistance float64
+	// Limit specifies the maximum number of branches returned, 10 if zero
+	Limit int
+}
+
+// NearbyBranch represents a bank branch and its distance from a location
+type NearbyBranch struct {
+	*Bank
+	// Distance specifies the great circle distance to the branch in kilometers
+	Distance float64 `json:"distance"`
+}
+
+// ExtractPIN returns the last pin code found in an address, e.g. 400024 in "KURLA-EAST,MUMBAI-400024".
+// It returns an empty string if the address has no pin code.
+func ExtractPIN(address string) string {
+	matches := pinPattern.FindAllStringSubmatch(address, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	last := matches[len(matches)-1]
+	return last[1] + last[2]
+}
+
+// NearestBranches returns the geocoded branches closest to the location that match the filter, nearest first.
+// Branches geocoded with GeoConfidenceNone are never returned.
+func (b *Finly) NearestBranches(ctx context.Context, lat, lon float64, filter BranchFilter) ([]*NearbyBranch, error) {
+	// A database built by an older importer has no geocoded branches
+	if b.missing["latitude"] {
+		return []*NearbyBranch{}, nil
+	}
+
+	if filter.MaxDistance <= 0 {
+		filter.MaxDistance = defaultMaxDistance
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultNearestLimit
+	}
+
+	// Narrow the search down to a bounding box around the location, the distance is checked precisely below
+	latDelta := filter.MaxDistance / kmPerDegree
+	lonDelta := filter.MaxDistance / (kmPerDegree * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+
+	args := []any{lat - latDelta, lat + latDelta, lon - lonDelta, lon + lonDelta}
+	query := getBanksInBoundsQuery
+	if filter.Code != "" {
+		query = getBanksInBoundsByCodeQuery
+		args = append([]any{filter.Code}, args...)
+	}
+
+	banks, err := b.queryBanks(ctx, query, args...)
+	if err != nil && !errors.Is(err, ErrBankNotFound) {
+		return nil, err
+	}
+
+	nearby := make([]*NearbyBranch, 0, len(banks))
+	for _, bank := range banks {
+		if bank.GeoConfidence == GeoConfidenceNone || !supportsAll(bank, filter.Rails) {
+			continue
+		}
+
+		distance := haversine(lat, lon, bank.Latitude, bank.Longitude)
+		if distance <= filter.MaxDistance {
+			nearby = append(nearby, &NearbyBranch{Bank: bank, Distance: distance})
+		}
+	}
+
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return nearby[i].Distance < nearby[j].Distance
+	})
+	if len(nearby) > filter.Limit {
+		nearby = nearby[:filter.Limit]
+	}
+
+	return nearby, nil
+}
+
+// supportsAll reports whether the bank branch supports every rail
+func supportsAll(bank *Bank, rails []Rail) bool {
+	for _, rail := range rails {
+		if !bank.Supports(rail) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// haversine returns the great circle distance between two coordinates in kilometers
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const radians = math.Pi / 180
+	dLat := (lat2 - lat1) * radians
+	dLon := (lon2 - lon1) * radians
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*radians)*math.Cos(lat2*radians)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractPIN(t *testing.T) {
+	testCases := []struct {
+		name     string
+		address  string
+		expected string
+	}{
+		{
+			name:     "pin after hyphen",
+			address:  "ABHYUDAYA BUILDING, KAMAL NATH MARG,NEHRU NAGAR,KURLA-EAST,MUMBAI-400024",
+			expected: "400024",
+		},
+		{
+			name:     "pin with a space",
+			address:  "SAMRIDDHI BHAVAN, 1 STRAND ROAD, KOLKATA 700 001",
+			expected: "700001",
+		},
+		{
+			name:     "last pin wins",
+			address:  "PLOT 110001, SECTOR 5, NOIDA 201301",
+			expected: "201301",
+		},
+		{
+			name:     "no pin",
+			address:  "NEAR BUS STAND, 12345 MAIN ROAD",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractPIN(tc.address))
+		})
+	}
+}
+
+func TestNearestBranches(t *testing.T) {
+	ctx := context.Background()
+	near := &Bank{Name: "Near", Code: "SBIN", Ifsc: "SBIN0000002", Neft: true, Upi: true,
+		Latitude: 19.0760, Longitude: 72.8777, GeoConfidence: GeoConfidenceHigh}
+	nearer := &Bank{Name: "Nearer", Code: "SBIN", Ifsc: "SBIN0000001", Neft: true,
+		Latitude: 19.0730, Longitude: 72.8820, GeoConfidence: GeoConfidenceLow}
+	ungeocoded := &Bank{Name: "Ungeocoded", Code: "SBIN", Ifsc: "SBIN0000003", Neft: true, GeoConfidence: GeoConfidenceNone}
+
+	testCases := []struct {
+		mockDB   func(mock sqlmock.Sqlmock)
+		name     string
+		expected []string
+		filter   BranchFilter
+	}{
+		{
+			name: "nearest first",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksInBoundsQuery)).WillReturnRows(bankRows(near, nearer, ungeocoded))
+			},
+			expected: []string{"SBIN0000001", "SBIN0000002"},
+		},
+		{
+			name:   "filtered by bank and rail",
+			filter: BranchFilter{Code: "SBIN", Rails: []Rail{RailUPI}},
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksInBoundsByCodeQuery)).WillReturnRows(bankRows(near, nearer))
+			},
+			expected: []string{"SBIN0000002"},
+		},
+		{
+			name:   "limited",
+			filter: BranchFilter{Limit: 1},
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksInBoundsQuery)).WillReturnRows(bankRows(near, nearer))
+			},
+			expected: []string{"SBIN0000001"},
+		},
+		{
+			name:   "out of range",
+			filter: BranchFilter{MaxDistance: 0.1},
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksInBoundsQuery)).WillReturnRows(bankRows(near))
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			branches, err := finly.NearestBranches(ctx, 19.0728, 72.8826, tc.filter)
+			assert.NoError(t, err)
+
+			ifscs := make([]string, 0, len(branches))
+			for _, branch := range branches {
+				ifscs = append(ifscs, branch.Ifsc)
+			}
+			assert.Equal(t, tc.expected, ifscs)
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// getVersionIDQuery is the query to get the id of an imported dataset version
+const getVersionIDQuery = `SELECT id FROM version WHERE version = ?`
+
+// historyColumns is the list of bank_history columns scanned by scanBank.
+// The history only keeps the upstream columns, the derived columns are left empty.
+const historyColumns = upstreamColumns + `,
+'', 0, 0, 'none',
+'', '', '', '', '', '',
+'', ''`
+
+// getBankByIFSCAsOfQuery is the query to get the snapshot of a bank that was valid in a dataset version
+const getBankByIFSCAsOfQuery = `SELECT ` + historyColumns + `
+FROM bank_history WHERE ifsc = ? AND valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)`
+
+// getIFSCLifecycleQuery is the query to get the first and the last version an ifsc was seen in
+const getIFSCLifecycleQuery = `SELECT f.version, l.version, l.id = (SELECT MAX(id) FROM version)
+FROM ifsc_lifecycle c
+JOIN version f ON f.id = c.first_seen
+JOIN version l ON l.id = c.last_seen
+WHERE c.ifsc = ?`
+
+// getBankChangesQuery is the query to get the field changes of an ifsc across versions
+const getBankChangesQuery = `SELECT v.version, c.field, COALESCE(c.old_value, ''), COALESCE(c.new_value, '')
+FROM bank_change c
+JOIN version v ON v.id = c.version_id
+WHERE c.ifsc = ? ORDER BY c.version_id, c.field`
+
+var ErrVersionNotFound = errors.New("version not found")
+
+// BankHistory represents the lifecycle of an IFSC across the imported dataset versions
+type BankHistory struct {
+	// Ifsc code of the bank branch
+	Ifsc string `json:"ifsc"`
+	// FirstSeen specifies the first dataset version the ifsc appeared in
+	FirstSeen string `json:"first_seen"`
+	// LastSeen specifies the last dataset version the ifsc appeared in
+	LastSeen string `json:"last_seen"`
+	// Active specifies whether the ifsc is present in the latest dataset version
+	Active bool `json:"active"`
+	// Changes specifies the field changes of the branch, oldest first
+	Changes []BankChange `json:"changes"`
+}
+
+// BankChange represents a change of a single field of a bank branch between two dataset versions
+type BankChange struct {
+	// Version specifies the dataset version that introduced the change
+	Version string `json:"version"`
+	// Field specifies the name of the changed field
+	Field string `json:"field"`
+	// Old specifies the value of the field before the change
+	Old string `json:"old"`
+	// New specifies the value of the field after the change
+	New string `json:"new"`
+}
+
+// GetBankByIFSCAsOf returns the Bank instance by its IFSC code as it was in the given dataset version,
+// e.g. the razorpay release tag v2.0.19.
+// It returns ErrVersionNotFound if the version was never imported and ErrBankNotFound
+// if the ifsc was not part of that version.
+func (b *Finly) GetBankByIFSCAsOf(ctx context.Context, ifsc, version string) (*Bank, error) {
+	var versionID int64
+	err := b.store.QueryRowContext(ctx, getVersionIDQuery, version).Scan(&versionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVersionNotFound
+		}
+
+		return nil, err
+	}
+
+	bank, err := scanBank(b.store.QueryRowContext(ctx, getBankByIFSCAsOfQuery, ifsc, versionID, versionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBankNotFound
+		}
+
+		return nil, err
+	}
+
+	// The snapshots only keep the raw address and contact, so they are parsed the same way as at import time
+	parseBankFields(bank)
+
+	return bank, nil
+}
+
+// GetBankHistory returns when an IFSC was first and last seen and how its fields changed across versions.
+// An ifsc that is no longer Active has been removed upstream and payouts to it are likely to bounce.
+// It returns ErrBankNotFound if the ifsc was never seen.
+func (b *Finly) GetBankHistory(ctx context.Context, ifsc string) (*BankHistory, error) {
+	history := BankHistory{Ifsc: ifsc, Changes: []BankChange{}}
+	err := b.store.QueryRowContext(ctx, getIFSCLifecycleQuery, ifsc).Scan(
+		&history.FirstSeen,
+		&history.LastSeen,
+		&history.Active,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBankNotFound
+		}
+
+		return nil, err
+	}
+
+	rows, err := b.store.QueryContext(ctx, getBankChangesQuery, ifsc)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change BankChange
+		err = rows.Scan(&change.Version, &change.Field, &change.Old, &change.New)
+		if err != nil {
+			return nil, err
+		}
+		history.Changes = append(history.Changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &history, nil
+}
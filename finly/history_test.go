@@ -0,0 +1,223 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBankByIFSCAsOf(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *Bank
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		ifsc           string
+		version        string
+	}{
+		{
+			name:    "ifsc present in version",
+			ifsc:    "ABHY0065001",
+			version: "v2.0.19",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getVersionIDQuery)).WithArgs("v2.0.19").WillReturnRows(
+					sqlmock.NewRows([]string{"id"}).AddRow(3),
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCAsOfQuery)).WithArgs("ABHY0065001", 3, 3).WillReturnRows(
+					sqlmock.NewRows(bankRowColumns).AddRow(
+						"Abhyudaya Co-operative Bank",
+						"ABHY",
+						"ABHY0065001",
+						"Abhyudaya Co-operative Bank IMPS",
+						"MUMBAI",
+						"MUMBAI",
+						"MAHARASHTRA",
+						"ABHYUDAYA BUILDING, KAMAL NATH MARG,NEHRU NAGAR,KURLA-EAST,MUMBAI-400024",
+						"+919653261383",
+						"1",
+						"0",
+						"MUMBAI",
+						"IN-MH",
+						"1",
+						"400065001",
+						"1",
+						"",
+						"",
+						0,
+						0,
+						"none",
+						"",
+						"",
+						"",
+						"",
+						"",
+						"",
+						"",
+						"",
+					),
+				)
+			},
+			expectedOutput: &Bank{
+				Name:          "Abhyudaya Co-operative Bank",
+				State:         "MAHARASHTRA",
+				City:          "MUMBAI",
+				Micr:          "400065001",
+				Branch:        "Abhyudaya Co-operative Bank IMPS",
+				Code:          "ABHY",
+				Contact:       "+919653261383",
+				Ifsc:          "ABHY0065001",
+				District:      "MUMBAI",
+				Address:       "ABHYUDAYA BUILDING, KAMAL NATH MARG,NEHRU NAGAR,KURLA-EAST,MUMBAI-400024",
+				Center:        "MUMBAI",
+				Iso3166:       "IN-MH",
+				Neft:          true,
+				Imps:          true,
+				Upi:           true,
+				Pin:           "400024",
+				GeoConfidence: GeoConfidenceNone,
+				StructuredAddress: BranchAddress{
+					Line:     "Abhyudaya Building, Kamal Nath Marg, Nehru Nagar",
+					Locality: "Kurla-East",
+					City:     "Mumbai",
+					District: "Mumbai",
+					State:    "Maharashtra",
+					PIN:      "400024",
+				},
+				Phones: []PhoneNumber{{Number: "+919653261383", Type: PhoneMobile}},
+			},
+		},
+		{
+			name:    "unknown version",
+			ifsc:    "ABHY0065001",
+			version: "v0.0.1",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getVersionIDQuery)).WithArgs("v0.0.1").WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrVersionNotFound,
+		},
+		{
+			name:    "ifsc absent in version",
+			ifsc:    "ABHY0069999",
+			version: "v2.0.19",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getVersionIDQuery)).WithArgs("v2.0.19").WillReturnRows(
+					sqlmock.NewRows([]string{"id"}).AddRow(3),
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCAsOfQuery)).WithArgs("ABHY0069999", 3, 3).WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrBankNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			bank, err := finly.GetBankByIFSCAsOf(ctx, tc.ifsc, tc.version)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, bank)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetBankHistory(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *BankHistory
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		ifsc           string
+	}{
+		{
+			name: "removed ifsc with changes",
+			ifsc: "ABHY0065001",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getIFSCLifecycleQuery)).WithArgs("ABHY0065001").WillReturnRows(
+					sqlmock.NewRows([]string{"first_seen", "last_seen", "active"}).AddRow("v2.0.17", "v2.0.18", false),
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankChangesQuery)).WithArgs("ABHY0065001").WillReturnRows(
+					sqlmock.NewRows([]string{"version", "field", "old_value", "new_value"}).
+						AddRow("v2.0.18", "contact", "", "+919653261383").
+						AddRow("v2.0.18", "rtgs", "false", "true"),
+				)
+			},
+			expectedOutput: &BankHistory{
+				Ifsc:      "ABHY0065001",
+				FirstSeen: "v2.0.17",
+				LastSeen:  "v2.0.18",
+				Active:    false,
+				Changes: []BankChange{
+					{Version: "v2.0.18", Field: "contact", Old: "", New: "+919653261383"},
+					{Version: "v2.0.18", Field: "rtgs", Old: "false", New: "true"},
+				},
+			},
+		},
+		{
+			name: "unknown ifsc",
+			ifsc: "ABHY0069999",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getIFSCLifecycleQuery)).WithArgs("ABHY0069999").WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrBankNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			history, err := finly.GetBankHistory(ctx, tc.ifsc)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, history)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// dateLayout is the layout of the dates in the holiday calendar
+	dateLayout = "2006-01-02"
+	// fullDay is the length of a day in the settlement schedules
+	fullDay = 24 * time.Hour
+	// maxSettlementSearchDays bounds the search for the next settlement window
+	maxSettlementSearchDays = 366
+	// daysInWeek is the number of days in a week
+	daysInWeek = 7
+)
+
+var (
+	ErrNoSettlementSchedule = errors.New("no settlement schedule for rail")
+	ErrNoSettlementWindow   = errors.New("no settlement window found")
+	ErrHolidaysNotCovered   = errors.New("holidays not covered for the year")
+)
+
+// Holiday represents a bank holiday
+type Holiday struct {
+	// Date specifies the date of the holiday in YYYY-MM-DD format
+	Date string `json:"date"`
+	// Name specifies the name of the holiday
+	Name string `json:"name"`
+}
+
+// holidayFile is the format of the holiday calendar data file
+type holidayFile struct {
+	// Years lists the years whose holidays are listed
+	Years []int `json:"years"`
+	// National lists the holidays observed by branches in every state
+	National []Holiday `json:"national"`
+	// States lists the regional holidays by the ISO 3166-2 code of the state
+	States map[string][]Holiday `json:"states"`
+}
+
+// SettlementSchedule represents when a rail settles payments during a day, in IST
+type SettlementSchedule struct {
+	// Interval specifies the time between two settlement batches, zero for a rail that settles continuously
+	Interval time.Duration
+	// Start specifies the time since midnight of the first settlement of the day
+	Start time.Duration
+	// End specifies the time since midnight of the last settlement of the day
+	End time.Duration
+	// WorkingDaysOnly specifies whether the rail settles only on the working days of the branch's state
+	WorkingDaysOnly bool
+}
+
+// DefaultSettlementSchedules returns the settlement schedules published by RBI: NEFT settles in half hourly
+// batches from 00:30 to 24:00 and RTGS settles continuously. RBI runs both rails every day of the year, but the
+// branches only clear the payments on the working days of their state, so the holidays of the branch's state are skipped.
+func DefaultSettlementSchedules() map[Rail]SettlementSchedule {
+	return map[Rail]SettlementSchedule{
+		RailNEFT: {Interval: 30 * time.Minute, Start: 30 * time.Minute, End: fullDay, WorkingDaysOnly: true},
+		RailRTGS: {Start: 0, End: fullDay, WorkingDaysOnly: true},
+	}
+}
+
+// HolidayCalendar answers whether a day is a working day for the branches in a state
+// and when the next settlement window of a rail is.
+// Besides the listed holidays, every Sunday and the second and fourth Saturdays of a month are bank holidays.
+type HolidayCalendar struct {
+	years     map[int]bool
+	national  map[string]string
+	states    map[string]map[string]string
+	schedules map[Rail]SettlementSchedule
+}
+
+// NewHolidayCalendar returns the holiday calendar loaded from the data file shipped with finly.
+// It returns an error if it fails to read the data file.
+func NewHolidayCalendar() (*HolidayCalendar, error) {
+	f, err := os.Open(getDataPath("holidays.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadHolidayCalendar(f)
+}
+
+// LoadHolidayCalendar returns a holiday calendar read from a JSON data file.
+// The file lists the "years" it covers, the "national" holidays and the regional holidays of the "states"
+// keyed by their ISO 3166-2 codes.
+// It returns an error if the file is malformed or lists a holiday outside the years it covers.
+func LoadHolidayCalendar(r io.Reader) (*HolidayCalendar, error) {
+	var file holidayFile
+	err := json.NewDecoder(r).Decode(&file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &HolidayCalendar{
+		years:     make(map[int]bool, len(file.Years)),
+		national:  make(map[string]string, len(file.National)),
+		states:    make(map[string]map[string]string, len(file.States)),
+		schedules: DefaultSettlementSchedules(),
+	}
+	for _, year := range file.Years {
+		c.years[year] = true
+	}
+
+	err = c.addHolidays(c.national, file.National)
+	if err != nil {
+		return nil, err
+	}
+
+	for state, holidays := range file.States {
+		code, ok := StateCode(state)
+		if !ok {
+			return nil, fmt.Errorf("unknown state %q in holiday calendar", state)
+		}
+		if c.states[code] == nil {
+			c.states[code] = make(map[string]string, len(holidays))
+		}
+
+		err = c.addHolidays(c.states[code], holidays)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *HolidayCalendar) addHolidays(dates map[string]string, holidays []Holiday) error {
+	for _, h := range holidays {
+		date, err := time.Parse(dateLayout, h.Date)
+		if err != nil {
+			return fmt.Errorf("invalid holiday date %q: %w", h.Date, err)
+		}
+		if !c.years[date.Year()] {
+			return fmt.Errorf("holiday date %q outside the years of the calendar", h.Date)
+		}
+		dates[h.Date] = h.Name
+	}
+
+	return nil
+}
+
+// WithSettlementSchedules returns a copy of the calendar that uses the given settlement schedules
+func (c *HolidayCalendar) WithSettlementSchedules(schedules map[Rail]SettlementSchedule) *HolidayCalendar {
+	return &HolidayCalendar{
+		years:     c.years,
+		national:  c.national,
+		states:    c.states,
+		schedules: schedules,
+	}
+}
+
+// Holiday returns the name of the holiday on the day for branches in the state.
+// The state is either a state name as found in Bank.State or an ISO 3166-2 code as found in Bank.Iso3166.
+// It returns false if the day is not a holiday and ErrHolidaysNotCovered if the calendar doesn't cover
+// the year of the day, as its holidays are unknown.
+func (c *HolidayCalendar) Holiday(state string, day time.Time) (string, bool, error) {
+	day = day.In(IST)
+	if !c.years[day.Year()] {
+		return "", false, fmt.Errorf("%w: %d", ErrHolidaysNotCovered, day.Year())
+	}
+
+	switch {
+	case day.Weekday() == time.Sunday:
+		return "Sunday", true, nil
+	case day.Weekday() == time.Saturday && (day.Day()-1)/daysInWeek == 1:
+		return "Second Saturday", true, nil
+	case day.Weekday() == time.Saturday && (day.Day()-1)/daysInWeek == 3:
+		return "Fourth Saturday", true, nil
+	}
+
+	date := day.Format(dateLayout)
+	if name, ok := c.national[date]; ok {
+		return name, true, nil
+	}
+
+	if code, ok := StateCode(state); ok {
+		if name, found := c.states[code][date]; found {
+			return name, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// IsWorkingDay reports whether the day is a working day for branches in the state.
+// The state is either a state name as found in Bank.State or an ISO 3166-2 code as found in Bank.Iso3166.
+// It returns ErrHolidaysNotCovered if the calendar doesn't cover the year of the day.
+func (c *HolidayCalendar) IsWorkingDay(state string, day time.Time) (bool, error) {
+	_, holiday, err := c.Holiday(state, day)
+	if err != nil {
+		return false, err
+	}
+
+	return !holiday, nil
+}
+
+// IsWorkingDayForBank reports whether the day is a working day for the bank branch.
+// It returns ErrHolidaysNotCovered if the calendar doesn't cover the year of the day.
+func (c *HolidayCalendar) IsWorkingDayForBank(bank *Bank, day time.Time) (bool, error) {
+	return c.IsWorkingDay(bankState(bank), day)
+}
+
+// NextSettlement returns the time of the first settlement window of the rail at or after the given time
+// for branches in the state.
+// It returns ErrNoSettlementSchedule if the rail has no settlement schedule, ErrNoSettlementWindow
+// if the rail doesn't settle within the next year and ErrHolidaysNotCovered if the rail only settles on
+// working days and the calendar doesn't cover a day it had to check.
+func (c *HolidayCalendar) NextSettlement(rail Rail, state string, after time.Time) (time.Time, error) {
+	schedule, ok := c.schedules[rail]
+	if !ok {
+		return time.Time{}, ErrNoSettlementSchedule
+	}
+
+	after = after.In(IST)
+	midnight := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, IST)
+	for i := 0; i < maxSettlementSearchDays; i++ {
+		date := midnight.AddDate(0, 0, i)
+		if schedule.WorkingDaysOnly {
+			working, err := c.IsWorkingDay(state, date)
+			if err != nil {
+				return time.Time{}, err
+			} else if !working {
+				continue
+			}
+		}
+
+		if window, open := schedule.next(after.Sub(date)); open {
+			return date.Add(window), nil
+		}
+	}
+
+	return time.Time{}, ErrNoSettlementWindow
+}
+
+// NextSettlementForBank returns the time of the first settlement window of the rail at or after the given time
+// for the bank branch.
+func (c *HolidayCalendar) NextSettlementForBank(rail Rail, bank *Bank, after time.Time) (time.Time, error) {
+	return c.NextSettlement(rail, bankState(bank), after)
+}
+
+// next returns the time since midnight of the first settlement of the day at or after the given time since midnight
+func (s SettlementSchedule) next(since time.Duration) (time.Duration, bool) {
+	if since <= s.Start {
+		return s.Start, true
+	}
+
+	if s.Interval == 0 {
+		return since, since <= s.End
+	}
+
+	batches := (since - s.Start + s.Interval - 1) / s.Interval
+	window := s.Start + batches*s.Interval
+
+	return window, window <= s.End
+}
+
+// bankState returns the ISO 3166-2 code of the bank's state, falling back to its state name
+func bankState(bank *Bank) string {
+	if bank.Iso3166 != "" {
+		return bank.Iso3166
+	}
+
+	return bank.State
+}
@@ -0,0 +1,275 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHolidays = `{
+	"years": [2025],
+	"national": [{"date": "2025-01-26", "name": "Republic Day"}],
+	"states": {
+		"IN-MH": [{"date": "2025-05-01", "name": "Maharashtra Day"}],
+		"KARNATAKA": [{"date": "2025-11-01", "name": "Kannada Rajyotsava"}]
+	}
+}`
+
+func TestHolidayCalendarIsWorkingDay(t *testing.T) {
+	calendar, err := LoadHolidayCalendar(strings.NewReader(testHolidays))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		day      time.Time
+		name     string
+		state    string
+		expected bool
+	}{
+		{name: "weekday", state: "IN-MH", day: time.Date(2025, time.May, 2, 0, 0, 0, 0, IST), expected: true},
+		{name: "sunday", state: "IN-MH", day: time.Date(2025, time.May, 4, 0, 0, 0, 0, IST), expected: false},
+		{name: "first saturday", state: "IN-MH", day: time.Date(2025, time.May, 3, 0, 0, 0, 0, IST), expected: true},
+		{name: "second saturday", state: "IN-MH", day: time.Date(2025, time.May, 10, 0, 0, 0, 0, IST), expected: false},
+		{name: "fourth saturday", state: "IN-MH", day: time.Date(2025, time.May, 24, 0, 0, 0, 0, IST), expected: false},
+		{name: "fifth saturday", state: "IN-MH", day: time.Date(2025, time.May, 31, 0, 0, 0, 0, IST), expected: true},
+		{name: "national holiday", state: "IN-KA", day: time.Date(2025, time.January, 27, 0, 0, 0, 0, IST).Add(-time.Hour), expected: false},
+		{name: "state holiday by state name", state: "Maharashtra", day: time.Date(2025, time.May, 1, 0, 0, 0, 0, IST), expected: false},
+		{name: "holiday of another state", state: "IN-KA", day: time.Date(2025, time.May, 1, 0, 0, 0, 0, IST), expected: true},
+		{name: "state keyed by name in the file", state: "IN-KA", day: time.Date(2025, time.November, 1, 0, 0, 0, 0, IST).UTC(), expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			working, err := calendar.IsWorkingDay(tc.state, tc.day)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, working)
+		})
+	}
+
+	working, err := calendar.IsWorkingDayForBank(&Bank{State: "MAHARASHTRA", Iso3166: "IN-MH"}, time.Date(2025, time.May, 1, 9, 0, 0, 0, IST))
+	require.NoError(t, err)
+	assert.False(t, working)
+}
+
+func TestHolidayCalendarNotCovered(t *testing.T) {
+	calendar, err := LoadHolidayCalendar(strings.NewReader(testHolidays))
+	require.NoError(t, err)
+
+	// The last day of the year is covered, the next one is not
+	lastDay := time.Date(2025, time.December, 31, 23, 59, 0, 0, IST)
+	working, err := calendar.IsWorkingDay("IN-MH", lastDay)
+	require.NoError(t, err)
+	assert.True(t, working)
+
+	_, err = calendar.IsWorkingDay("IN-MH", lastDay.Add(time.Minute))
+	assert.ErrorIs(t, err, ErrHolidaysNotCovered)
+
+	_, _, err = calendar.Holiday("IN-MH", time.Date(2024, time.December, 31, 0, 0, 0, 0, IST))
+	assert.ErrorIs(t, err, ErrHolidaysNotCovered)
+
+	// The last batch of the year settles at midnight, the rail can't be settled on the days after it
+	settlement, err := calendar.NextSettlement(RailNEFT, "IN-MH", lastDay)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, IST).Equal(settlement), settlement)
+
+	_, err = calendar.NextSettlement(RailNEFT, "IN-MH", lastDay.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrHolidaysNotCovered)
+
+	// A rail settling every day doesn't need the calendar
+	schedules := DefaultSettlementSchedules()
+	schedules[RailRTGS] = SettlementSchedule{Start: 0, End: fullDay}
+	settlement, err = calendar.WithSettlementSchedules(schedules).NextSettlement(RailRTGS, "IN-MH", lastDay.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, lastDay.Add(time.Hour).Equal(settlement), settlement)
+}
+
+func TestHolidayCalendarNextSettlement(t *testing.T) {
+	calendar, err := LoadHolidayCalendar(strings.NewReader(testHolidays))
+	require.NoError(t, err)
+
+	schedules := DefaultSettlementSchedules()
+	schedules[RailRTGS] = SettlementSchedule{Start: 7 * time.Hour, End: 18 * time.Hour, WorkingDaysOnly: true}
+	calendar = calendar.WithSettlementSchedules(schedules)
+
+	testCases := []struct {
+		expectedError  error
+		after          time.Time
+		expectedOutput time.Time
+		name           string
+		rail           Rail
+	}{
+		{
+			name:           "neft batch",
+			rail:           RailNEFT,
+			after:          time.Date(2025, time.May, 2, 10, 10, 0, 0, IST),
+			expectedOutput: time.Date(2025, time.May, 2, 10, 30, 0, 0, IST),
+		},
+		{
+			name:           "neft last batch of the day",
+			rail:           RailNEFT,
+			after:          time.Date(2025, time.May, 2, 23, 45, 0, 0, IST),
+			expectedOutput: time.Date(2025, time.May, 3, 0, 0, 0, 0, IST),
+		},
+		{
+			name:           "neft on state holiday",
+			rail:           RailNEFT,
+			after:          time.Date(2025, time.May, 1, 10, 10, 0, 0, IST),
+			expectedOutput: time.Date(2025, time.May, 2, 0, 30, 0, 0, IST),
+		},
+		{
+			name:           "rtgs within window",
+			rail:           RailRTGS,
+			after:          time.Date(2025, time.May, 2, 10, 10, 0, 0, IST),
+			expectedOutput: time.Date(2025, time.May, 2, 10, 10, 0, 0, IST),
+		},
+		{
+			name:           "rtgs on state holiday",
+			rail:           RailRTGS,
+			after:          time.Date(2025, time.May, 1, 10, 10, 0, 0, IST),
+			expectedOutput: time.Date(2025, time.May, 2, 7, 0, 0, 0, IST),
+		},
+		{
+			name:           "rtgs after window before weekend",
+			rail:           RailRTGS,
+			after:          time.Date(2025, time.May, 9, 19, 0, 0, 0, IST),
+			expectedOutput: time.Date(2025, time.May, 12, 7, 0, 0, 0, IST),
+		},
+		{
+			name:          "rail without schedule",
+			rail:          RailUPI,
+			after:         time.Date(2025, time.May, 1, 10, 10, 0, 0, IST),
+			expectedError: ErrNoSettlementSchedule,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			settlement, err := calendar.NextSettlement(tc.rail, "IN-MH", tc.after)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, tc.expectedOutput.Equal(settlement), "expected %s, got %s", tc.expectedOutput, settlement)
+			}
+		})
+	}
+}
+
+func TestShippedHolidayCalendar(t *testing.T) {
+	calendar, err := NewHolidayCalendar()
+	require.NoError(t, err)
+
+	// Kannada Rajyotsava falls on the first saturday of November 2025, a working day in the other states
+	kannadaRajyotsava := time.Date(2025, time.November, 1, 10, 10, 0, 0, IST)
+
+	testCases := []struct {
+		after          time.Time
+		expectedOutput time.Time
+		name           string
+		rail           Rail
+		state          string
+	}{
+		{
+			name:           "neft on a regional holiday",
+			rail:           RailNEFT,
+			state:          "KARNATAKA",
+			after:          kannadaRajyotsava,
+			expectedOutput: time.Date(2025, time.November, 3, 0, 30, 0, 0, IST),
+		},
+		{
+			name:           "neft on the regional holiday of another state",
+			rail:           RailNEFT,
+			state:          "MAHARASHTRA",
+			after:          kannadaRajyotsava,
+			expectedOutput: time.Date(2025, time.November, 1, 10, 30, 0, 0, IST),
+		},
+		{
+			name:           "rtgs on diwali",
+			rail:           RailRTGS,
+			state:          "IN-GJ",
+			after:          time.Date(2025, time.October, 20, 10, 10, 0, 0, IST),
+			expectedOutput: time.Date(2025, time.October, 21, 0, 0, 0, 0, IST),
+		},
+		{
+			name:           "rtgs on christmas",
+			rail:           RailRTGS,
+			state:          "IN-KL",
+			after:          time.Date(2026, time.December, 25, 10, 10, 0, 0, IST),
+			expectedOutput: time.Date(2026, time.December, 28, 0, 0, 0, 0, IST),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			settlement, err := calendar.NextSettlement(tc.rail, tc.state, tc.after)
+			require.NoError(t, err)
+			assert.True(t, tc.expectedOutput.Equal(settlement), "expected %s, got %s", tc.expectedOutput, settlement)
+		})
+	}
+
+	for _, holiday := range []struct {
+		state string
+		day   time.Time
+		name  string
+	}{
+		{state: "UTTAR PRADESH", day: time.Date(2026, time.March, 4, 0, 0, 0, 0, IST), name: "Holi"},
+		{state: "DELHI", day: time.Date(2025, time.March, 31, 0, 0, 0, 0, IST), name: "Id-ul-Fitr"},
+		{state: "WEST BENGAL", day: time.Date(2025, time.October, 1, 0, 0, 0, 0, IST), name: "Maha Navami"},
+		{state: "TAMIL NADU", day: time.Date(2026, time.January, 15, 0, 0, 0, 0, IST), name: "Pongal"},
+	} {
+		name, ok, err := calendar.Holiday(holiday.state, holiday.day)
+		require.NoError(t, err)
+		assert.True(t, ok, "%s in %s", holiday.name, holiday.state)
+		assert.Equal(t, holiday.name, name)
+	}
+}
+
+func TestLoadHolidayCalendarErrors(t *testing.T) {
+	_, err := LoadHolidayCalendar(strings.NewReader(`{"states": {"ATLANTIS": []}}`))
+	assert.Error(t, err)
+
+	_, err = LoadHolidayCalendar(strings.NewReader(`{"years": [2025], "national": [{"date": "26-01-2025"}]}`))
+	assert.Error(t, err)
+
+	_, err = LoadHolidayCalendar(strings.NewReader(`{"years": [2025], "national": [{"date": "2026-01-26"}]}`))
+	assert.Error(t, err)
+}
+
+func TestStateCode(t *testing.T) {
+	testCases := []struct {
+		state    string
+		expected string
+		found    bool
+	}{
+		{state: "MAHARASHTRA", expected: "IN-MH", found: true},
+		{state: "Jammu & Kashmir", expected: "IN-JK", found: true},
+		{state: "ORISSA", expected: "IN-OR", found: true},
+		{state: "IN-KA", expected: "IN-KA", found: true},
+		{state: "IN-TS", expected: "IN-TG", found: true},
+		{state: "ATLANTIS", expected: "", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.state, func(t *testing.T) {
+			code, found := StateCode(tc.state)
+			assert.Equal(t, tc.expected, code)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
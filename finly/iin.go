@@ -0,0 +1,136 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// bankMasterColumns is the list of bank_master columns scanned by scanBankMaster
+const bankMasterColumns = `m.code,
+COALESCE(NULLIF(m.name, ''), (SELECT name FROM bank WHERE code = m.code LIMIT 1), ''),
+COALESCE(m.ifsc, ''), COALESCE(m.micr, ''), COALESCE(m.type, ''), COALESCE(m.iin, ''),
+COALESCE(m.apbs, 0), COALESCE(m.ach_credit, 0), COALESCE(m.ach_debit, 0), COALESCE(m.nach_debit, 0)`
+
+// getBankMasterByIINQuery is the query to get a bank by its npci issuer identification number
+const getBankMasterByIINQuery = `SELECT ` + bankMasterColumns + `
+FROM bank_master m WHERE m.iin = ?`
+
+// getAPBSBankMastersQuery is the query to get the banks live on the aadhaar payments bridge system
+const getAPBSBankMastersQuery = `SELECT ` + bankMasterColumns + `
+FROM bank_master m WHERE m.apbs = 1 ORDER BY m.code`
+
+// iinLength is the length of an npci issuer identification number
+const iinLength = 6
+
+var ErrInvalidIIN = errors.New("invalid iin")
+
+// BankMaster represents the bank level data published by npci for a bank, as opposed to the branch level data of Bank
+type BankMaster struct {
+	// Code specifies the 4 letter code of the bank
+	Code string `json:"code"`
+	// Name specifies the name of the bank
+	Name string `json:"name"`
+	// Ifsc specifies the ifsc of the head office of the bank
+	Ifsc string `json:"ifsc"`
+	// Micr specifies the micr of the head office of the bank
+	Micr string `json:"micr"`
+	// Type specifies the kind of bank, e.g. PSB for a public sector bank
+	Type string `json:"type"`
+	// Iin specifies the 6 digit issuer identification number npci assigned to the bank for aadhaar based payments
+	Iin string `json:"iin"`
+	// Apbs specifies whether the bank is live on the aadhaar payments bridge system used for dbt payments
+	Apbs bool `json:"apbs"`
+	// AchCredit specifies whether the bank supports ACH credit
+	AchCredit bool `json:"ach_credit"`
+	// AchDebit specifies whether the bank supports ACH debit
+	AchDebit bool `json:"ach_debit"`
+	// NachDebit specifies whether the bank supports NACH debit
+	NachDebit bool `json:"nach_debit"`
+}
+
+// GetBankByIIN returns the bank with the npci issuer identification number used for aadhaar based payments.
+// It returns ErrInvalidIIN if the iin is not 6 digits long and ErrBankNotFound if no bank has the iin.
+func (b *Finly) GetBankByIIN(ctx context.Context, iin string) (*BankMaster, error) {
+	iin = strings.TrimSpace(iin)
+	if len(iin) != iinLength || strings.Trim(iin, "0123456789") != "" {
+		return nil, ErrInvalidIIN
+	}
+
+	bank, err := scanBankMaster(b.store.QueryRowContext(ctx, getBankMasterByIINQuery, iin))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBankNotFound
+		}
+
+		return nil, err
+	}
+
+	return bank, nil
+}
+
+// GetAPBSBanks returns the banks live on the aadhaar payments bridge system, ordered by code.
+// It returns ErrBankNotFound if there are none.
+func (b *Finly) GetAPBSBanks(ctx context.Context) ([]*BankMaster, error) {
+	rows, err := b.store.QueryContext(ctx, getAPBSBankMastersQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []*BankMaster
+	for rows.Next() {
+		var bank *BankMaster
+		bank, err = scanBankMaster(rows)
+		if err != nil {
+			return nil, err
+		}
+		banks = append(banks, bank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(banks) == 0 {
+		return nil, ErrBankNotFound
+	}
+
+	return banks, nil
+}
+
+// scanBankMaster scans a row selected with bankMasterColumns into a BankMaster
+func scanBankMaster(row rowScanner) (*BankMaster, error) {
+	var m BankMaster
+	err := row.Scan(
+		&m.Code,
+		&m.Name,
+		&m.Ifsc,
+		&m.Micr,
+		&m.Type,
+		&m.Iin,
+		&m.Apbs,
+		&m.AchCredit,
+		&m.AchDebit,
+		&m.NachDebit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
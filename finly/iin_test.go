@@ -0,0 +1,131 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bankMasterRowColumns = []string{
+	"code", "name", "ifsc", "micr", "type", "iin", "apbs", "ach_credit", "ach_debit", "nach_debit",
+}
+
+func TestGetBankByIIN(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *BankMaster
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		iin            string
+	}{
+		{
+			name: "known iin",
+			iin:  " 607153 ",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBankMasterByIINQuery)).WithArgs("607153").WillReturnRows(
+					sqlmock.NewRows(bankMasterRowColumns).
+						AddRow("SBIN", "State Bank of India", "SBIN0000001", "700002021", "PSB", "607153", true, true, true, true),
+				)
+			},
+			expectedOutput: &BankMaster{
+				Code:      "SBIN",
+				Name:      "State Bank of India",
+				Ifsc:      "SBIN0000001",
+				Micr:      "700002021",
+				Type:      "PSB",
+				Iin:       "607153",
+				Apbs:      true,
+				AchCredit: true,
+				AchDebit:  true,
+				NachDebit: true,
+			},
+		},
+		{
+			name: "unknown iin",
+			iin:  "999999",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBankMasterByIINQuery)).WithArgs("999999").WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrBankNotFound,
+		},
+		{
+			name:          "invalid iin",
+			iin:           "60715A",
+			mockDB:        func(mock sqlmock.Sqlmock) {},
+			expectedError: ErrInvalidIIN,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			bank, err := finly.GetBankByIIN(ctx, tc.iin)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, bank)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetAPBSBanks(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getAPBSBankMastersQuery)).WillReturnRows(
+		sqlmock.NewRows(bankMasterRowColumns).
+			AddRow("PUNB", "Punjab National Bank", "PUNB0244200", "110024001", "PSB", "607027", true, true, true, true).
+			AddRow("SBIN", "State Bank of India", "SBIN0000001", "700002021", "PSB", "607153", true, true, true, true),
+	)
+	mock.ExpectQuery(regexp.QuoteMeta(getAPBSBankMastersQuery)).WillReturnRows(sqlmock.NewRows(bankMasterRowColumns))
+
+	finly := &Finly{
+		store: db,
+	}
+
+	banks, err := finly.GetAPBSBanks(ctx)
+	assert.NoError(t, err)
+	require.Len(t, banks, 2)
+	assert.Equal(t, "PUNB", banks[0].Code)
+	assert.Equal(t, "607153", banks[1].Iin)
+
+	_, err = finly.GetAPBSBanks(ctx)
+	assert.EqualError(t, err, ErrBankNotFound.Error())
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,320 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+const (
+	// acronymScore is the score of a typed name that is the acronym of the canonical name, e.g. SBI
+	acronymScore = 0.95
+	// bankNameWeight is the weight of the bank name in the score of a beneficiary, the rest going to the branch
+	bankNameWeight = 0.7
+	// minAcronymLength is the length of the shortest acronym recognised, shorter ones match too many banks
+	minAcronymLength = 2
+	// minWordSimilarity is the lowest similarity of two words still considered the same word with a typo
+	minWordSimilarity = 0.7
+)
+
+// nameStopWords are left out of the acronym of a name, e.g. OF in State Bank of India
+var nameStopWords = map[string]bool{
+	"AND": true, "OF": true, "THE": true,
+}
+
+// nameOptionalWords are often left out of a name without making it another bank, e.g. Central Bank for Central Bank
+// of India, Saraswat Bank for Saraswat Co-operative Bank or Kotak for Kotak Mahindra Bank. They are the generic words
+// of the kinds of banks and the words of a brand that are never used alone. Unlike the noise words they are still
+// compared when the names have nothing else in common.
+var nameOptionalWords = map[string]bool{
+	"INDIA":       true,
+	"COOPERATIVE": true,
+	"SAHAKARI":    true,
+	"SMALL":       true,
+	"FINANCE":     true,
+	"PAYMENTS":    true,
+	"MAHINDRA":    true,
+}
+
+// nameNoiseWords are ignored when comparing names
+var nameNoiseWords = map[string]bool{
+	"THE": true, "LTD": true, "LIMITED": true, "BRANCH": true,
+}
+
+// nameSynonyms maps the common short forms of the words of a bank or branch name to their canonical form
+var nameSynonyms = map[string]string{
+	"BK":    "BANK",
+	"COOP":  "COOPERATIVE",
+	"CORP":  "CORPORATION",
+	"CORPN": "CORPORATION",
+	"INTL":  "INTERNATIONAL",
+	"NATL":  "NATIONAL",
+	"RD":    "ROAD",
+	"BR":    "BRANCH",
+	"MKT":   "MARKET",
+	"NGR":   "NAGAR",
+}
+
+// BeneficiaryMatch represents how closely the bank and branch names typed by a user match those of an ifsc
+type BeneficiaryMatch struct {
+	// Ifsc specifies the ifsc the names were matched against
+	Ifsc string `json:"ifsc"`
+	// BankName specifies the canonical name of the bank of the ifsc
+	BankName string `json:"bank_name"`
+	// Branch specifies the canonical name of the branch of the ifsc
+	Branch string `json:"branch"`
+	// BankScore specifies how closely the typed bank name matches the canonical one, from 0 to 1
+	BankScore float64 `json:"bank_score"`
+	// BranchScore specifies how closely the typed branch name matches the canonical one, from 0 to 1
+	BranchScore float64 `json:"branch_score"`
+	// Score specifies the overall confidence that the typed names refer to the ifsc, from 0 to 1.
+	// It only accounts for the names that were typed.
+	Score float64 `json:"score"`
+}
+
+// MatchBeneficiary fuzzy matches the bank and branch names typed by a user against those of an ifsc.
+// Either name may be empty, in which case it is left out of the score.
+// It returns ErrBankNotFound if the ifsc does not exist.
+func (b *Finly) MatchBeneficiary(ctx context.Context, ifsc, bankName, branch string) (*BeneficiaryMatch, error) {
+	bank, err := b.GetBankByIFSC(ctx, ifsc)
+	if err != nil {
+		return nil, err
+	}
+
+	match := BeneficiaryMatch{
+		Ifsc:     bank.Ifsc,
+		BankName: bank.Name,
+		Branch:   bank.Branch,
+	}
+
+	typedBank, typedBranch := strings.TrimSpace(bankName) != "", strings.TrimSpace(branch) != ""
+	if typedBank {
+		match.BankScore = MatchName(bankName, bank.Name)
+	}
+	if typedBranch {
+		match.BranchScore = MatchName(branch, bank.Branch)
+	}
+
+	switch {
+	case typedBank && typedBranch:
+		match.Score = bankNameWeight*match.BankScore + (1-bankNameWeight)*match.BranchScore
+	case typedBank:
+		match.Score = match.BankScore
+	case typedBranch:
+		match.Score = match.BranchScore
+	}
+
+	return &match, nil
+}
+
+// MatchName returns how closely a typed name matches a canonical bank or branch name, from 0 to 1.
+// Case, punctuation, legal suffixes and common short forms are ignored, words are compared with
+// a tolerance for typos, and acronyms such as SBI for State Bank of India are recognised.
+// Every significant word of either name has to be found in the other one, so that Bank of India
+// does not match State Bank of India nor Indian Bank match Indian Overseas Bank.
+func MatchName(typed, canonical string) float64 {
+	typedWords, canonicalWords := nameWords(typed), nameWords(canonical)
+	if len(typedWords) == 0 || len(canonicalWords) == 0 {
+		return 0
+	}
+
+	// Users often leave out the word bank, e.g. HDFC for HDFC Bank
+	compared, against := typedWords, canonicalWords
+	if !containsWord(compared, "BANK") {
+		against = withoutWord(against, "BANK")
+	} else if !containsWord(against, "BANK") {
+		compared = withoutWord(compared, "BANK")
+	}
+
+	compared, against = significantWords(compared), significantWords(against)
+
+	var score float64
+	if len(compared) > 0 && len(against) > 0 {
+		score = wordsSimilarity(compared, against)
+	}
+	if score < acronymScore && isAcronym(typedWords, canonicalWords) {
+		score = acronymScore
+	}
+
+	return score
+}
+
+// nameWords returns the upper cased words of a name without punctuation and noise words,
+// with their short forms expanded
+func nameWords(name string) []string {
+	// Join the words broken by a hyphen, e.g. CO-OPERATIVE, before splitting on punctuation
+	name = strings.ReplaceAll(strings.ToUpper(name), "-", "")
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := words[:0]
+	for _, word := range words {
+		if synonym, ok := nameSynonyms[word]; ok {
+			word = synonym
+		}
+		if nameNoiseWords[word] {
+			continue
+		}
+		kept = append(kept, word)
+	}
+
+	return kept
+}
+
+// containsWord reports whether the words contain word
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutWord returns a copy of the words without word
+func withoutWord(words []string, word string) []string {
+	kept := make([]string, 0, len(words))
+	for _, w := range words {
+		if w != word {
+			kept = append(kept, w)
+		}
+	}
+
+	return kept
+}
+
+// significantWords returns the words without the stop words, and without the optional words unless nothing else is left.
+// The words are returned as is if they are all stop words.
+func significantWords(words []string) []string {
+	var kept, required []string
+	for _, word := range words {
+		if nameStopWords[word] {
+			continue
+		}
+		kept = append(kept, word)
+		if !nameOptionalWords[word] {
+			required = append(required, word)
+		}
+	}
+
+	switch {
+	case len(required) > 0:
+		return required
+	case len(kept) > 0:
+		return kept
+	default:
+		return words
+	}
+}
+
+// wordsSimilarity multiplies how well the words of each name are covered by the other one, so that a word
+// missing from either name weighs as much as all the others and the names only score high when they match as sets
+func wordsSimilarity(a, b []string) float64 {
+	return coverage(a, b) * coverage(b, a)
+}
+
+// coverage averages the similarities of the words with their closest word among others,
+// the words without a close enough word count as missing
+func coverage(words, others []string) float64 {
+	var total float64
+	for _, word := range words {
+		if similarity := closestWord(word, others); similarity >= minWordSimilarity {
+			total += similarity
+		}
+	}
+
+	return total / float64(len(words))
+}
+
+// closestWord returns the similarity of a word with its closest word among others
+func closestWord(word string, others []string) float64 {
+	var best float64
+	for _, other := range others {
+		if similarity := wordSimilarity(word, other); similarity > best {
+			best = similarity
+		}
+	}
+
+	return best
+}
+
+// wordSimilarity returns 1 minus the levenshtein distance of two words relative to the length of the longer one
+func wordSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the number of single rune edits needed to turn a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := range a {
+		current[0] = i + 1
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			current[j+1] = min(previous[j+1]+1, current[j]+1, previous[j]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// isAcronym reports whether the typed words are an acronym of the canonical words, e.g. SBI or SBI BANK for
+// State Bank of India and BOB for Bank of Baroda
+func isAcronym(typed, canonical []string) bool {
+	var compact strings.Builder
+	for _, word := range typed {
+		// A trailing BANK is often added to the acronym, e.g. SBI BANK
+		if word == "BANK" && compact.Len() > 0 {
+			continue
+		}
+		compact.WriteString(word)
+	}
+	if compact.Len() < minAcronymLength {
+		return false
+	}
+
+	var all, significant strings.Builder
+	for _, word := range canonical {
+		initial := []rune(word)[0]
+		all.WriteRune(initial)
+		if !nameStopWords[word] {
+			significant.WriteRune(initial)
+		}
+	}
+
+	return compact.String() == all.String() || compact.String() == significant.String()
+}
@@ -0,0 +1,244 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchName(t *testing.T) {
+	testCases := []struct {
+		name      string
+		typed     string
+		canonical string
+		minScore  float64
+		maxScore  float64
+	}{
+		{
+			name:      "same name in another case",
+			typed:     "state bank of india",
+			canonical: "State Bank of India",
+			minScore:  1,
+			maxScore:  1,
+		},
+		{
+			name:      "acronym",
+			typed:     "SBI",
+			canonical: "State Bank of India",
+			minScore:  acronymScore,
+			maxScore:  acronymScore,
+		},
+		{
+			name:      "acronym with stop word and bank",
+			typed:     "B.O.B. Bank",
+			canonical: "Bank of Baroda",
+			minScore:  acronymScore,
+			maxScore:  acronymScore,
+		},
+		{
+			name:      "short forms, typo and legal suffix",
+			typed:     "Abhyudya Coop Bank Ltd",
+			canonical: "Abhyudaya Co-operative Bank",
+			minScore:  0.85,
+			maxScore:  0.99,
+		},
+		{
+			name:      "bank left out",
+			typed:     "HDFC",
+			canonical: "HDFC Bank",
+			minScore:  1,
+			maxScore:  1,
+		},
+		{
+			name:      "another bank",
+			typed:     "Punjab National Bank",
+			canonical: "State Bank of India",
+			maxScore:  0.5,
+		},
+		{
+			name:      "another bank with the name of a bank",
+			typed:     "Bank of India",
+			canonical: "State Bank of India",
+			maxScore:  0.5,
+		},
+		{
+			name:      "another bank ending with the name of a bank",
+			typed:     "Bank of India",
+			canonical: "Union Bank of India",
+			maxScore:  0.5,
+		},
+		{
+			name:      "another bank starting with the name of a bank",
+			typed:     "Indian Bank",
+			canonical: "Indian Overseas Bank",
+			maxScore:  0.7,
+		},
+		{
+			name:      "of india left out",
+			typed:     "Central Bank",
+			canonical: "Central Bank of India",
+			minScore:  1,
+			maxScore:  1,
+		},
+		{
+			name:      "kind of bank left out",
+			typed:     "Saraswat Bank",
+			canonical: "Saraswat Co-operative Bank",
+			minScore:  1,
+			maxScore:  1,
+		},
+		{
+			name:      "kinds of bank left out",
+			typed:     "AU Bank",
+			canonical: "AU Small Finance Bank",
+			minScore:  1,
+			maxScore:  1,
+		},
+		{
+			name:      "second word of a brand left out",
+			typed:     "Kotak",
+			canonical: "Kotak Mahindra Bank",
+			minScore:  1,
+			maxScore:  1,
+		},
+		{
+			name:      "another kind of bank of the same place",
+			typed:     "Karnataka Bank",
+			canonical: "Karnataka State Co-operative Apex Bank",
+			maxScore:  0.5,
+		},
+		{
+			name:      "bank of india",
+			typed:     "Bank of India",
+			canonical: "Bank of India",
+			minScore:  1,
+			maxScore:  1,
+		},
+		{
+			name:      "bank of another place",
+			typed:     "Bank of Baroda",
+			canonical: "Bank of India",
+			maxScore:  0.5,
+		},
+		{
+			name:      "nothing typed",
+			typed:     " - ",
+			canonical: "State Bank of India",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			score := MatchName(tc.typed, tc.canonical)
+			assert.GreaterOrEqual(t, score, tc.minScore)
+			assert.LessOrEqual(t, score, tc.maxScore)
+		})
+	}
+}
+
+func TestMatchBeneficiary(t *testing.T) {
+	ctx := context.Background()
+	bank := &Bank{
+		Name:   "State Bank of India",
+		Code:   "SBIN",
+		Ifsc:   "SBIN0000001",
+		Branch: "KOLKATA MAIN",
+	}
+
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *BeneficiaryMatch
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		ifsc           string
+		bankName       string
+		branch         string
+	}{
+		{
+			name:     "bank and branch",
+			ifsc:     "SBIN0000001",
+			bankName: "SBI",
+			branch:   "Kolkata Main Br",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("SBIN0000001").WillReturnRows(bankRows(bank))
+			},
+			expectedOutput: &BeneficiaryMatch{
+				Ifsc:        "SBIN0000001",
+				BankName:    "State Bank of India",
+				Branch:      "KOLKATA MAIN",
+				BankScore:   acronymScore,
+				BranchScore: 1,
+				Score:       bankNameWeight*acronymScore + (1 - bankNameWeight),
+			},
+		},
+		{
+			name:     "bank only",
+			ifsc:     "SBIN0000001",
+			bankName: "Punjab National Bank",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("SBIN0000001").WillReturnRows(bankRows(bank))
+			},
+			expectedOutput: &BeneficiaryMatch{
+				Ifsc:      "SBIN0000001",
+				BankName:  "State Bank of India",
+				Branch:    "KOLKATA MAIN",
+				BankScore: MatchName("Punjab National Bank", "State Bank of India"),
+				Score:     MatchName("Punjab National Bank", "State Bank of India"),
+			},
+		},
+		{
+			name:     "unknown ifsc",
+			ifsc:     "SBIN0999999",
+			bankName: "SBI",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("SBIN0999999").WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrBankNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			match, err := finly.MatchBeneficiary(ctx, tc.ifsc, tc.bankName, tc.branch)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tc.expectedOutput.Score, match.Score, 1e-9)
+				match.Score = tc.expectedOutput.Score
+				assert.EqualValues(t, tc.expectedOutput, match)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
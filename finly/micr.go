@@ -0,0 +1,153 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// getBanksByMICRQuery is the query to get the bank branches by micr
+const getBanksByMICRQuery = `SELECT ` + bankColumns + `
+FROM bank WHERE micr = ? ORDER BY ifsc`
+
+// getCityByMICRCityCodeQuery is the query to get the most common city of the branches whose micr falls in a city code.
+// The micr range keeps the lookup on the micr index.
+const getCityByMICRCityCodeQuery = `SELECT city FROM bank
+WHERE micr >= ? AND micr < ? AND city != ''
+GROUP BY city ORDER BY COUNT(*) DESC LIMIT 1`
+
+// getBankByMICRBankCodeQuery is the query to get a bank by the bank code of its micr in bank_master
+const getBankByMICRBankCodeQuery = `SELECT m.code, COALESCE(NULLIF(m.name, ''), (SELECT name FROM bank WHERE code = m.code LIMIT 1), '')
+FROM bank_master m WHERE substr(m.micr, 4, 3) = ? ORDER BY m.code LIMIT 1`
+
+// getBankByBranchMICRBankCodeQuery is the query to get the most common bank of the branches with the micr bank code,
+// it is the fallback for the banks missing from bank_master
+const getBankByBranchMICRBankCodeQuery = `SELECT code, name FROM bank
+WHERE substr(micr, 4, 3) = ?
+GROUP BY code, name ORDER BY COUNT(*) DESC LIMIT 1`
+
+const (
+	// micrLength is the length of a micr code
+	micrLength = 9
+	// micrPartLength is the length of the city, bank and branch codes of a micr
+	micrPartLength = 3
+)
+
+var ErrInvalidMICR = errors.New("invalid micr")
+
+// MICR represents a 9 digit magnetic ink character recognition code printed on cheques
+type MICR struct {
+	// CityCode specifies the 3 digit code of the city, it usually matches the first digits of the city's pin code
+	CityCode string `json:"city_code"`
+	// BankCode specifies the 3 digit code of the bank
+	BankCode string `json:"bank_code"`
+	// BranchCode specifies the 3 digit code of the branch
+	BranchCode string `json:"branch_code"`
+}
+
+// DecodedMICR represents a micr resolved against the dataset.
+// The resolved fields are empty when the dataset doesn't know the corresponding code.
+type DecodedMICR struct {
+	MICR
+	// City specifies the name of the city of the city code
+	City string `json:"city,omitempty"`
+	// Code specifies the 4 letter code of the bank of the bank code
+	Code string `json:"code,omitempty"`
+	// Name specifies the name of the bank of the bank code
+	Name string `json:"name,omitempty"`
+}
+
+// ParseMICR splits a 9 digit micr into its city, bank and branch codes.
+// It returns ErrInvalidMICR if the micr is not made of 9 digits.
+func ParseMICR(micr string) (*MICR, error) {
+	micr = strings.TrimSpace(micr)
+	if len(micr) != micrLength {
+		return nil, ErrInvalidMICR
+	}
+
+	for _, r := range micr {
+		if r < '0' || r > '9' {
+			return nil, ErrInvalidMICR
+		}
+	}
+
+	return &MICR{
+		CityCode:   micr[:micrPartLength],
+		BankCode:   micr[micrPartLength : 2*micrPartLength],
+		BranchCode: micr[2*micrPartLength:],
+	}, nil
+}
+
+// String returns the 9 digit micr
+func (m *MICR) String() string {
+	return m.CityCode + m.BankCode + m.BranchCode
+}
+
+// GetBranchesByMICR returns the bank branches by their micr.
+// It returns ErrInvalidMICR if the micr is malformed and ErrBankNotFound if no branch has the micr.
+func (b *Finly) GetBranchesByMICR(ctx context.Context, micr string) ([]*Bank, error) {
+	m, err := ParseMICR(micr)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.queryBanks(ctx, getBanksByMICRQuery, m.String())
+}
+
+// DecodeMICR splits a micr into its city, bank and branch codes and resolves the city code to a city
+// and the bank code to a bank, even when the micr itself is missing from the dataset.
+// It returns ErrInvalidMICR if the micr is malformed.
+func (b *Finly) DecodeMICR(ctx context.Context, micr string) (*DecodedMICR, error) {
+	m, err := ParseMICR(micr)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := DecodedMICR{MICR: *m}
+	lower := m.CityCode + strings.Repeat("0", 2*micrPartLength)
+	upper := nextCode(m.CityCode) + strings.Repeat("0", 2*micrPartLength)
+	err = b.store.QueryRowContext(ctx, getCityByMICRCityCodeQuery, lower, upper).Scan(&decoded.City)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	err = b.store.QueryRowContext(ctx, getBankByMICRBankCodeQuery, m.BankCode).Scan(&decoded.Code, &decoded.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = b.store.QueryRowContext(ctx, getBankByBranchMICRBankCodeQuery, m.BankCode).Scan(&decoded.Code, &decoded.Name)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+// nextCode returns the numeric code following a code of digits, keeping its width, e.g. 400 becomes 401.
+// The code following 999 is the ":" sentinel, which sorts after every digit.
+func nextCode(code string) string {
+	digits := []byte(code)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return string(digits)
+		}
+		digits[i] = '0'
+	}
+
+	return ":"
+}
@@ -0,0 +1,191 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMICR(t *testing.T) {
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *MICR
+		name           string
+		micr           string
+	}{
+		{
+			name:           "valid micr",
+			micr:           "400065001",
+			expectedOutput: &MICR{CityCode: "400", BankCode: "065", BranchCode: "001"},
+		},
+		{
+			name:          "short micr",
+			micr:          "40006500",
+			expectedError: ErrInvalidMICR,
+		},
+		{
+			name:          "non numeric micr",
+			micr:          "40006500A",
+			expectedError: ErrInvalidMICR,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			micr, err := ParseMICR(tc.micr)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, micr)
+				assert.Equal(t, tc.micr, micr.String())
+			}
+		})
+	}
+}
+
+func TestNextCode(t *testing.T) {
+	assert.Equal(t, "401", nextCode("400"))
+	assert.Equal(t, "410", nextCode("409"))
+	assert.Equal(t, ":", nextCode("999"))
+}
+
+func TestDecodeMICR(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *DecodedMICR
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		micr           string
+	}{
+		{
+			name: "bank found in bank master",
+			micr: "400065999",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getCityByMICRCityCodeQuery)).WithArgs("400000000", "401000000").WillReturnRows(
+					sqlmock.NewRows([]string{"city"}).AddRow("MUMBAI"),
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByMICRBankCodeQuery)).WithArgs("065").WillReturnRows(
+					sqlmock.NewRows([]string{"code", "name"}).AddRow("ABHY", "Abhyudaya Co-operative Bank"),
+				)
+			},
+			expectedOutput: &DecodedMICR{
+				MICR: MICR{CityCode: "400", BankCode: "065", BranchCode: "999"},
+				City: "MUMBAI",
+				Code: "ABHY",
+				Name: "Abhyudaya Co-operative Bank",
+			},
+		},
+		{
+			name: "bank found in branches",
+			micr: "110002001",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getCityByMICRCityCodeQuery)).WithArgs("110000000", "111000000").WillReturnRows(
+					sqlmock.NewRows([]string{"city"}).AddRow("DELHI"),
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByMICRBankCodeQuery)).WithArgs("002").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByBranchMICRBankCodeQuery)).WithArgs("002").WillReturnRows(
+					sqlmock.NewRows([]string{"code", "name"}).AddRow("SBIN", "State Bank of India"),
+				)
+			},
+			expectedOutput: &DecodedMICR{
+				MICR: MICR{CityCode: "110", BankCode: "002", BranchCode: "001"},
+				City: "DELHI",
+				Code: "SBIN",
+				Name: "State Bank of India",
+			},
+		},
+		{
+			name: "unknown codes",
+			micr: "999999999",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getCityByMICRCityCodeQuery)).WithArgs("999000000", ":000000").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByMICRBankCodeQuery)).WithArgs("999").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(getBankByBranchMICRBankCodeQuery)).WithArgs("999").WillReturnError(sql.ErrNoRows)
+			},
+			expectedOutput: &DecodedMICR{
+				MICR: MICR{CityCode: "999", BankCode: "999", BranchCode: "999"},
+			},
+		},
+		{
+			name:          "invalid micr",
+			micr:          "4000650",
+			mockDB:        func(mock sqlmock.Sqlmock) {},
+			expectedError: ErrInvalidMICR,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			decoded, err := finly.DecodeMICR(ctx, tc.micr)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, decoded)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetBranchesByMICR(t *testing.T) {
+	ctx := context.Background()
+	branch := &Bank{
+		Name: "Abhyudaya Co-operative Bank",
+		Code: "ABHY",
+		Ifsc: "ABHY0065001",
+		Micr: "400065001",
+		City: "MUMBAI",
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getBanksByMICRQuery)).WithArgs("400065001").WillReturnRows(bankRows(branch))
+
+	finly := &Finly{
+		store: db,
+	}
+
+	banks, err := finly.GetBranchesByMICR(ctx, "400065001")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*Bank{branch}, banks)
+
+	_, err = finly.GetBranchesByMICR(ctx, "ABC")
+	assert.EqualError(t, err, ErrInvalidMICR.Error())
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,157 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// countryCode is the calling code of india
+	countryCode = "91"
+	// internationalPrefix is dialled before the country code when calling from india
+	internationalPrefix = "00"
+	// nationalNumberLength is the length of an indian phone number without the country code and the trunk prefix,
+	// i.e. the std code followed by the subscriber number for a landline
+	nationalNumberLength = 10
+	// minTollFreeLength is the length of the shortest toll free number, e.g. 1800 followed by 6 digits
+	minTollFreeLength = 10
+	// maxTollFreeLength is the length of the longest toll free number, e.g. 1800 followed by 7 digits
+	maxTollFreeLength = 11
+	// maxAlternateLength is the length of the longest alternate suffix, e.g. 57 in 022-24123456/57
+	maxAlternateLength = 5
+)
+
+var (
+	// contactSeparator matches the separators between the phone numbers of a contact
+	contactSeparator = regexp.MustCompile(`(?i)[,;&\n]+|\s+or\s+|\s+and\s+`)
+	// extensionPattern matches the extension of a phone number, e.g. "EXTN. 123" or "X-12"
+	extensionPattern = regexp.MustCompile(`(?i)\(?\s*\b(?:EXTENSION|EXTN|EXT|X)\b\.?\s*[:\-]?\s*(\d{1,6})\s*\)?`)
+	// nonDigit matches everything but the digits of a phone number
+	nonDigit = regexp.MustCompile(`\D`)
+	// stdGrouping matches a landline written with its std code apart from the subscriber number, e.g. (080) 23456780,
+	// as subscriber numbers start with 2 to 5 while mobile numbers may start with the same digits as a std code
+	stdGrouping = regexp.MustCompile(`^\s*(?:\+?91[\s\-]*)?\(?0?\d{2,4}\)?[\s\-]+([2-5]\d{5,7})\s*$`)
+	// subscriberNumber matches a landline written without its std code, e.g. 24123457 in 022 24123456 / 24123457
+	subscriberNumber = regexp.MustCompile(`^\s*[2-5]\d{5,7}\s*$`)
+)
+
+// PhoneType specifies the kind of line a phone number belongs to
+type PhoneType string
+
+const (
+	// PhoneLandline is a fixed line, dialled with its std code
+	PhoneLandline PhoneType = "landline"
+	// PhoneMobile is a mobile number, which starts with 6, 7, 8 or 9.
+	// Only those starting with 9 are typed as mobiles unless written apart from a std code.
+	PhoneMobile PhoneType = "mobile"
+	// PhoneTollFree is a toll free number starting with 1800 or 1860
+	PhoneTollFree PhoneType = "tollfree"
+)
+
+// PhoneNumber represents a phone number of a bank branch
+type PhoneNumber struct {
+	// Number specifies the phone number in E.164 form, e.g. +912224123456
+	Number string `json:"number"`
+	// Type specifies whether the number is a landline, a mobile or a toll free number.
+	// It is empty when the number may be either a landline or a mobile, e.g. 08023456780.
+	Type PhoneType `json:"type,omitempty"`
+	// Extension specifies the extension to dial once connected, if any
+	Extension string `json:"extension,omitempty"`
+}
+
+// ParseContact parses the free text contact of a branch into its phone numbers.
+// Numbers that cannot be dialled unambiguously, such as landlines written without their std code, are left out.
+func ParseContact(contact string) []PhoneNumber {
+	var (
+		phones []PhoneNumber
+		seen   = make(map[PhoneNumber]bool)
+	)
+	for _, part := range contactSeparator.Split(contact, -1) {
+		var extension string
+		if match := extensionPattern.FindStringSubmatch(part); match != nil {
+			extension = match[1]
+			part = extensionPattern.ReplaceAllString(part, " ")
+		}
+
+		// A short number after a slash replaces the last digits of the previous one, e.g. 022-24123456/57,
+		// and a subscriber number after a landline is dialled with its std code, e.g. 022 24123456 / 24123457
+		var (
+			previous   string
+			landline   bool
+			subscriber int
+		)
+		for _, alternate := range strings.Split(part, "/") {
+			digits := nonDigit.ReplaceAllString(alternate, "")
+			switch {
+			case len(digits) > 0 && len(digits) <= maxAlternateLength && len(digits) < len(previous):
+				digits = previous[:len(previous)-len(digits)] + digits
+			case landline && len(digits) == subscriber && subscriberNumber.MatchString(alternate):
+				digits = previous[:len(previous)-subscriber] + digits
+			default:
+				match := stdGrouping.FindStringSubmatch(alternate)
+				landline, subscriber = match != nil, 0
+				if landline {
+					subscriber = len(match[1])
+				}
+			}
+			if digits == "" {
+				continue
+			}
+			previous = digits
+
+			phone, ok := parsePhoneNumber(digits, landline)
+			if !ok {
+				continue
+			}
+			phone.Extension = extension
+			if !seen[phone] {
+				seen[phone] = true
+				phones = append(phones, phone)
+			}
+		}
+	}
+
+	return phones
+}
+
+// parsePhoneNumber returns the E.164 form and the type of a phone number given as digits only.
+// landline reports whether the number was written with its std code apart.
+// A number starting with 6, 7 or 8, with or without the trunk prefix, is either a mobile or a landline of a std code
+// starting with the same digit, e.g. 080 for Bangalore or 079 for Ahmedabad, and is left without a type.
+func parsePhoneNumber(digits string, landline bool) (PhoneNumber, bool) {
+	switch {
+	case strings.HasPrefix(digits, internationalPrefix+countryCode):
+		digits = digits[len(internationalPrefix+countryCode):]
+	case strings.HasPrefix(digits, countryCode) && len(digits) == len(countryCode)+nationalNumberLength:
+		digits = digits[len(countryCode):]
+	}
+	digits = strings.TrimPrefix(digits, "0")
+
+	switch {
+	case (strings.HasPrefix(digits, "1800") || strings.HasPrefix(digits, "1860")) &&
+		len(digits) >= minTollFreeLength && len(digits) <= maxTollFreeLength:
+		return PhoneNumber{Number: "+" + countryCode + digits, Type: PhoneTollFree}, true
+	case len(digits) != nationalNumberLength || digits[0] == '0':
+		return PhoneNumber{}, false
+	case !landline && strings.ContainsRune("678", rune(digits[0])):
+		return PhoneNumber{Number: "+" + countryCode + digits}, true
+	case !landline && digits[0] == '9':
+		return PhoneNumber{Number: "+" + countryCode + digits, Type: PhoneMobile}, true
+	default:
+		return PhoneNumber{Number: "+" + countryCode + digits, Type: PhoneLandline}, true
+	}
+}
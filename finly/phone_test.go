@@ -0,0 +1,106 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContact(t *testing.T) {
+	testCases := []struct {
+		name           string
+		contact        string
+		expectedOutput []PhoneNumber
+	}{
+		{
+			name:           "mobile in e164 form",
+			contact:        "+919653261383",
+			expectedOutput: []PhoneNumber{{Number: "+919653261383", Type: PhoneMobile}},
+		},
+		{
+			name:    "landline with std code and alternate suffix",
+			contact: "022-24123456/57",
+			expectedOutput: []PhoneNumber{
+				{Number: "+912224123456", Type: PhoneLandline},
+				{Number: "+912224123457", Type: PhoneLandline},
+			},
+		},
+		{
+			name:    "several numbers with an extension",
+			contact: "080 23456780 EXTN. 214; 09876543210 or 1800 425 3800",
+			expectedOutput: []PhoneNumber{
+				{Number: "+918023456780", Type: PhoneLandline, Extension: "214"},
+				{Number: "+919876543210", Type: PhoneMobile},
+				{Number: "+9118004253800", Type: PhoneTollFree},
+			},
+		},
+		{
+			name:    "numbers with the trunk prefix",
+			contact: "08023456780, 07926581234, 09876543210, 02224123456",
+			expectedOutput: []PhoneNumber{
+				{Number: "+918023456780"},
+				{Number: "+917926581234"},
+				{Number: "+919876543210", Type: PhoneMobile},
+				{Number: "+912224123456", Type: PhoneLandline},
+			},
+		},
+		{
+			name:    "numbers without the trunk prefix",
+			contact: "8023456780 / 7926581234 / 9876543210",
+			expectedOutput: []PhoneNumber{
+				{Number: "+918023456780"},
+				{Number: "+917926581234"},
+				{Number: "+919876543210", Type: PhoneMobile},
+			},
+		},
+		{
+			name:    "landline with std code and alternate subscriber number",
+			contact: "022 24123456 / 24123457",
+			expectedOutput: []PhoneNumber{
+				{Number: "+912224123456", Type: PhoneLandline},
+				{Number: "+912224123457", Type: PhoneLandline},
+			},
+		},
+		{
+			name:    "subscriber number after a mobile",
+			contact: "9876543210 / 24123457",
+			expectedOutput: []PhoneNumber{
+				{Number: "+919876543210", Type: PhoneMobile},
+			},
+		},
+		{
+			name:    "international prefix and duplicates",
+			contact: "0091 40 23456789, +91-40-23456789",
+			expectedOutput: []PhoneNumber{
+				{Number: "+914023456789", Type: PhoneLandline},
+			},
+		},
+		{
+			name:    "number without std code",
+			contact: "2345678",
+		},
+		{
+			name: "empty contact",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, ParseContact(tc.contact))
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"time"
+)
+
+// Rail is a payment rail of the indian banking system
+type Rail string
+
+const (
+	// RailIMPS is the Immediate Payment Service
+	RailIMPS Rail = "IMPS"
+	// RailNEFT is the National Electronic Funds Transfer
+	RailNEFT Rail = "NEFT"
+	// RailRTGS is the Real Time Gross Settlement
+	RailRTGS Rail = "RTGS"
+	// RailUPI is the Unified Payments Interface
+	RailUPI Rail = "UPI"
+)
+
+// Rails lists every supported rail in the order they are evaluated
+var Rails = []Rail{RailIMPS, RailNEFT, RailRTGS, RailUPI}
+
+// Reason explains why a rail is not eligible for a payment
+type Reason string
+
+const (
+	// ReasonUnsupported means the destination branch does not support the rail
+	ReasonUnsupported Reason = "rail not supported by the branch"
+	// ReasonInvalidAmount means the amount is not positive, no rail carries such a payment
+	ReasonInvalidAmount Reason = "amount not positive"
+	// ReasonBelowMinimum means the amount is below the minimum amount of the rail
+	ReasonBelowMinimum Reason = "amount below the rail minimum"
+	// ReasonAboveMaximum means the amount is above the maximum amount of the rail
+	ReasonAboveMaximum Reason = "amount above the rail maximum"
+	// ReasonOutsideWindow means the rail is not operating at the time of the payment
+	ReasonOutsideWindow Reason = "outside the rail operating window"
+)
+
+const (
+	// rupee is the number of paise in a rupee
+	rupee int64 = 100
+	// lakh is the number of paise in a lakh rupees
+	lakh = 100000 * rupee
+	// istOffset is the offset of Indian Standard Time from UTC in seconds
+	istOffset = 5*60*60 + 30*60
+)
+
+// IST is the Indian Standard Time zone the rail operating windows are expressed in
+var IST = time.FixedZone("IST", istOffset)
+
+// Window represents a daily window in IST during which a rail accepts payments
+type Window struct {
+	// Days specifies the weekdays the window applies to, it applies to every day if empty
+	Days []time.Weekday
+	// Start specifies the time since midnight the window opens at
+	Start time.Duration
+	// End specifies the time since midnight the window closes at, a window ending before it starts runs past midnight
+	End time.Duration
+}
+
+// RailRule represents the constraints a payment must satisfy to be sent over a rail.
+// Amounts are in paise and a zero amount means there is no limit.
+type RailRule struct {
+	// MinAmount specifies the minimum amount of a payment
+	MinAmount int64
+	// MaxAmount specifies the maximum amount of a payment
+	MaxAmount int64
+	// Windows specifies when the rail accepts payments, the rail operates round the clock if empty
+	Windows []Window
+}
+
+// RailRules configures the rule of every rail, a rail without a rule only requires the branch to support it
+type RailRules map[Rail]RailRule
+
+// DefaultRailRules returns the rules published by RBI and NPCI: NEFT, RTGS, IMPS and UPI operate round the clock,
+// RTGS requires at least ₹2 lakh, IMPS is capped at ₹5 lakh and UPI at ₹1 lakh.
+func DefaultRailRules() RailRules {
+	return RailRules{
+		RailIMPS: {MaxAmount: 5 * lakh},
+		RailNEFT: {},
+		RailRTGS: {MinAmount: 2 * lakh},
+		RailUPI:  {MaxAmount: 1 * lakh},
+	}
+}
+
+// RailEligibility represents whether a payment can be sent over a rail
+type RailEligibility struct {
+	// Rail specifies the evaluated rail
+	Rail Rail `json:"rail"`
+	// Eligible specifies whether the payment can be sent over the rail
+	Eligible bool `json:"eligible"`
+	// Reason specifies why the rail is not eligible, it is empty if the rail is eligible
+	Reason Reason `json:"reason,omitempty"`
+}
+
+// RailEngine decides which rails a payment to a branch can be sent over
+type RailEngine struct {
+	finly *Finly
+	rules RailRules
+}
+
+// NewRailEngine returns a new rail engine that evaluates payments against the rules
+func NewRailEngine(f *Finly, rules RailRules) *RailEngine {
+	return &RailEngine{finly: f, rules: rules}
+}
+
+// EligibleRails returns the eligibility of every rail for a payment of amount paise to the ifsc at the given time.
+// It returns ErrBankNotFound if the ifsc does not exist.
+func (e *RailEngine) EligibleRails(ctx context.Context, ifsc string, amount int64, at time.Time) ([]RailEligibility, error) {
+	bank, err := e.finly.GetBankByIFSC(ctx, ifsc)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Evaluate(bank, amount, at), nil
+}
+
+// Evaluate returns the eligibility of every rail for a payment of amount paise to the bank at the given time
+func (e *RailEngine) Evaluate(bank *Bank, amount int64, at time.Time) []RailEligibility {
+	eligibility := make([]RailEligibility, 0, len(Rails))
+	for _, rail := range Rails {
+		reason := e.check(bank, rail, amount, at)
+		eligibility = append(eligibility, RailEligibility{
+			Rail:     rail,
+			Eligible: reason == "",
+			Reason:   reason,
+		})
+	}
+
+	return eligibility
+}
+
+// check returns why the payment can't be sent over the rail, it returns an empty reason if it can
+func (e *RailEngine) check(bank *Bank, rail Rail, amount int64, at time.Time) Reason {
+	if amount <= 0 {
+		return ReasonInvalidAmount
+	}
+	if !bank.Supports(rail) {
+		return ReasonUnsupported
+	}
+
+	rule := e.rules[rail]
+	if rule.MinAmount > 0 && amount < rule.MinAmount {
+		return ReasonBelowMinimum
+	}
+	if rule.MaxAmount > 0 && amount > rule.MaxAmount {
+		return ReasonAboveMaximum
+	}
+	if !rule.isOpen(at) {
+		return ReasonOutsideWindow
+	}
+
+	return ""
+}
+
+// Supports reports whether the bank branch supports the rail
+func (b *Bank) Supports(rail Rail) bool {
+	switch rail {
+	case RailIMPS:
+		return b.Imps
+	case RailNEFT:
+		return b.Neft
+	case RailRTGS:
+		return b.Rtgs
+	case RailUPI:
+		return b.Upi
+	default:
+		return false
+	}
+}
+
+// isOpen reports whether any window of the rule is open at the given time
+func (r RailRule) isOpen(at time.Time) bool {
+	if len(r.Windows) == 0 {
+		return true
+	}
+
+	for _, w := range r.Windows {
+		if w.isOpen(at) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOpen reports whether the window is open at the given time
+func (w Window) isOpen(at time.Time) bool {
+	at = at.In(IST)
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, IST)
+	sinceMidnight := at.Sub(midnight)
+
+	if w.End <= w.Start {
+		// The window runs past midnight, so the early hours belong to the window opened the previous day
+		if sinceMidnight < w.End {
+			return w.appliesTo(at.AddDate(0, 0, -1).Weekday())
+		}
+
+		return sinceMidnight >= w.Start && w.appliesTo(at.Weekday())
+	}
+
+	return sinceMidnight >= w.Start && sinceMidnight < w.End && w.appliesTo(at.Weekday())
+}
+
+// appliesTo reports whether the window applies to the weekday
+func (w Window) appliesTo(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
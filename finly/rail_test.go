@@ -0,0 +1,173 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRailEngineEvaluate(t *testing.T) {
+	weekdayNoon := time.Date(2023, time.October, 18, 12, 0, 0, 0, IST)
+	sundayNoon := time.Date(2023, time.October, 22, 12, 0, 0, 0, IST)
+	mondayEarly := time.Date(2023, time.October, 23, 1, 0, 0, 0, IST)
+
+	testCases := []struct {
+		rules          RailRules
+		bank           *Bank
+		at             time.Time
+		name           string
+		expectedOutput []RailEligibility
+		amount         int64
+	}{
+		{
+			name:   "small amount with default rules",
+			rules:  DefaultRailRules(),
+			bank:   &Bank{Imps: true, Neft: true, Rtgs: true, Upi: true},
+			amount: 5000 * rupee,
+			at:     weekdayNoon,
+			expectedOutput: []RailEligibility{
+				{Rail: RailIMPS, Eligible: true},
+				{Rail: RailNEFT, Eligible: true},
+				{Rail: RailRTGS, Eligible: false, Reason: ReasonBelowMinimum},
+				{Rail: RailUPI, Eligible: true},
+			},
+		},
+		{
+			name:   "large amount to a branch without upi",
+			rules:  DefaultRailRules(),
+			bank:   &Bank{Imps: true, Neft: true, Rtgs: true},
+			amount: 10 * lakh,
+			at:     weekdayNoon,
+			expectedOutput: []RailEligibility{
+				{Rail: RailIMPS, Eligible: false, Reason: ReasonAboveMaximum},
+				{Rail: RailNEFT, Eligible: true},
+				{Rail: RailRTGS, Eligible: true},
+				{Rail: RailUPI, Eligible: false, Reason: ReasonUnsupported},
+			},
+		},
+		{
+			name:   "zero amount",
+			rules:  DefaultRailRules(),
+			bank:   &Bank{Imps: true, Neft: true, Rtgs: true},
+			amount: 0,
+			at:     weekdayNoon,
+			expectedOutput: []RailEligibility{
+				{Rail: RailIMPS, Eligible: false, Reason: ReasonInvalidAmount},
+				{Rail: RailNEFT, Eligible: false, Reason: ReasonInvalidAmount},
+				{Rail: RailRTGS, Eligible: false, Reason: ReasonInvalidAmount},
+				{Rail: RailUPI, Eligible: false, Reason: ReasonInvalidAmount},
+			},
+		},
+		{
+			name:   "negative amount without limits",
+			rules:  RailRules{},
+			bank:   &Bank{Imps: true, Neft: true, Rtgs: true, Upi: true},
+			amount: -1 * rupee,
+			at:     weekdayNoon,
+			expectedOutput: []RailEligibility{
+				{Rail: RailIMPS, Eligible: false, Reason: ReasonInvalidAmount},
+				{Rail: RailNEFT, Eligible: false, Reason: ReasonInvalidAmount},
+				{Rail: RailRTGS, Eligible: false, Reason: ReasonInvalidAmount},
+				{Rail: RailUPI, Eligible: false, Reason: ReasonInvalidAmount},
+			},
+		},
+		{
+			name: "operating windows",
+			rules: RailRules{
+				RailNEFT: {Windows: []Window{{
+					Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+					Start: 8 * time.Hour,
+					End:   19 * time.Hour,
+				}}},
+				RailRTGS: {Windows: []Window{{
+					Days:  []time.Weekday{time.Sunday},
+					Start: 22 * time.Hour,
+					End:   2 * time.Hour,
+				}}},
+			},
+			bank:   &Bank{Neft: true, Rtgs: true},
+			amount: 3 * lakh,
+			at:     mondayEarly,
+			expectedOutput: []RailEligibility{
+				{Rail: RailIMPS, Eligible: false, Reason: ReasonUnsupported},
+				{Rail: RailNEFT, Eligible: false, Reason: ReasonOutsideWindow},
+				{Rail: RailRTGS, Eligible: true},
+				{Rail: RailUPI, Eligible: false, Reason: ReasonUnsupported},
+			},
+		},
+		{
+			name: "closed on sunday",
+			rules: RailRules{
+				RailNEFT: {Windows: []Window{{Days: []time.Weekday{time.Monday}, Start: 0, End: 24 * time.Hour}}},
+			},
+			bank:   &Bank{Neft: true},
+			amount: 100 * rupee,
+			at:     sundayNoon.UTC(),
+			expectedOutput: []RailEligibility{
+				{Rail: RailIMPS, Eligible: false, Reason: ReasonUnsupported},
+				{Rail: RailNEFT, Eligible: false, Reason: ReasonOutsideWindow},
+				{Rail: RailRTGS, Eligible: false, Reason: ReasonUnsupported},
+				{Rail: RailUPI, Eligible: false, Reason: ReasonUnsupported},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := NewRailEngine(nil, tc.rules)
+			assert.EqualValues(t, tc.expectedOutput, engine.Evaluate(tc.bank, tc.amount, tc.at))
+		})
+	}
+}
+
+func TestRailEngineEligibleRails(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("ABHY0065001").WillReturnRows(bankRows(&Bank{
+		Name: "Abhyudaya Co-operative Bank",
+		Code: "ABHY",
+		Ifsc: "ABHY0065001",
+		Imps: true,
+		Neft: true,
+	}))
+	mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("ABHY0069999").WillReturnError(sql.ErrNoRows)
+
+	engine := NewRailEngine(&Finly{store: db}, DefaultRailRules())
+
+	eligibility, err := engine.EligibleRails(ctx, "ABHY0065001", 3*lakh, time.Now())
+	assert.NoError(t, err)
+	assert.EqualValues(t, []RailEligibility{
+		{Rail: RailIMPS, Eligible: true},
+		{Rail: RailNEFT, Eligible: true},
+		{Rail: RailRTGS, Eligible: false, Reason: ReasonUnsupported},
+		{Rail: RailUPI, Eligible: false, Reason: ReasonUnsupported},
+	}, eligibility)
+
+	_, err = engine.EligibleRails(ctx, "ABHY0069999", 3*lakh, time.Now())
+	assert.EqualError(t, err, ErrBankNotFound.Error())
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// getIFSCRedirectQuery is the query to get the successor of a retired ifsc
+const getIFSCRedirectQuery = `SELECT successor_ifsc FROM ifsc_redirect WHERE legacy_ifsc = ?`
+
+// getMergerByCodeQuery is the query to get the merger of a bank by its code
+const getMergerByCodeQuery = `SELECT code, name, successor_code, successor_name, effective_date
+FROM bank_merger WHERE code = ?`
+
+// maxRedirects is the maximum number of redirects followed for an ifsc, it guards against redirect cycles
+const maxRedirects = 5
+
+var ErrRedirectNotFound = errors.New("redirect not found")
+
+// Merger represents the amalgamation of a bank into its successor bank
+type Merger struct {
+	// Code specifies the code of the merged bank
+	Code string `json:"code"`
+	// Name specifies the name of the merged bank
+	Name string `json:"name"`
+	// SuccessorCode specifies the code of the bank it was merged into
+	SuccessorCode string `json:"successor_code"`
+	// SuccessorName specifies the name of the bank it was merged into
+	SuccessorName string `json:"successor_name"`
+	// EffectiveDate specifies the date the amalgamation came into effect in YYYY-MM-DD format
+	EffectiveDate string `json:"effective_date"`
+}
+
+// IFSCRedirect represents a retired ifsc and the ifsc that replaced it
+type IFSCRedirect struct {
+	// LegacyIfsc specifies the retired ifsc
+	LegacyIfsc string `json:"legacy_ifsc"`
+	// SuccessorIfsc specifies the ifsc that replaced the retired one, it is empty if only the merger is known
+	SuccessorIfsc string `json:"successor_ifsc,omitempty"`
+	// Merger specifies the amalgamation that retired the ifsc, it is nil if the ifsc was retired for another reason
+	Merger *Merger `json:"merger,omitempty"`
+}
+
+// FollowRedirect makes GetBankByIFSC return the successor branch when the ifsc has been retired
+// and replaced, e.g. after a bank amalgamation.
+func FollowRedirect() LookupOption {
+	return func(o *lookupOptions) {
+		o.followRedirect = true
+	}
+}
+
+// GetIFSCRedirect returns the successor ifsc and the merger metadata for a retired ifsc.
+// It returns ErrRedirectNotFound if neither a redirect nor a merger of the ifsc's bank is known.
+func (b *Finly) GetIFSCRedirect(ctx context.Context, ifsc string) (*IFSCRedirect, error) {
+	redirect := IFSCRedirect{LegacyIfsc: ifsc}
+	err := b.store.QueryRowContext(ctx, getIFSCRedirectQuery, ifsc).Scan(&redirect.SuccessorIfsc)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if len(ifsc) >= bankCodeLength {
+		var m Merger
+		err = b.store.QueryRowContext(ctx, getMergerByCodeQuery, ifsc[:bankCodeLength]).Scan(
+			&m.Code,
+			&m.Name,
+			&m.SuccessorCode,
+			&m.SuccessorName,
+			&m.EffectiveDate,
+		)
+		if err == nil {
+			redirect.Merger = &m
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	if redirect.SuccessorIfsc == "" && redirect.Merger == nil {
+		return nil, ErrRedirectNotFound
+	}
+
+	return &redirect, nil
+}
+
+// resolveRedirect follows the redirects of an ifsc and returns the ifsc currently in use
+func (b *Finly) resolveRedirect(ctx context.Context, ifsc string) (string, error) {
+	for i := 0; i < maxRedirects; i++ {
+		var successor string
+		err := b.store.QueryRowContext(ctx, getIFSCRedirectQuery, ifsc).Scan(&successor)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ifsc, nil
+		} else if err != nil {
+			return "", err
+		}
+		ifsc = successor
+	}
+
+	return ifsc, nil
+}
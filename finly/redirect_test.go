@@ -0,0 +1,212 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mergerRowColumns = []string{"code", "name", "successor_code", "successor_name", "effective_date"}
+
+func TestGetIFSCRedirect(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *IFSCRedirect
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		ifsc           string
+	}{
+		{
+			name: "redirect of a merged bank",
+			ifsc: "VIJB0001234",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getIFSCRedirectQuery)).WithArgs("VIJB0001234").WillReturnRows(
+					sqlmock.NewRows([]string{"successor_ifsc"}).AddRow("BARB0VJ1234"),
+				)
+				mock.ExpectQuery(regexp.QuoteMeta(getMergerByCodeQuery)).WithArgs("VIJB").WillReturnRows(
+					sqlmock.NewRows(mergerRowColumns).AddRow("VIJB", "Vijaya Bank", "BARB", "Bank of Baroda", "2019-04-01"),
+				)
+			},
+			expectedOutput: &IFSCRedirect{
+				LegacyIfsc:    "VIJB0001234",
+				SuccessorIfsc: "BARB0VJ1234",
+				Merger: &Merger{
+					Code:          "VIJB",
+					Name:          "Vijaya Bank",
+					SuccessorCode: "BARB",
+					SuccessorName: "Bank of Baroda",
+					EffectiveDate: "2019-04-01",
+				},
+			},
+		},
+		{
+			name: "merged bank without a redirect",
+			ifsc: "BKDN0000001",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getIFSCRedirectQuery)).WithArgs("BKDN0000001").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(getMergerByCodeQuery)).WithArgs("BKDN").WillReturnRows(
+					sqlmock.NewRows(mergerRowColumns).AddRow("BKDN", "Dena Bank", "BARB", "Bank of Baroda", "2019-04-01"),
+				)
+			},
+			expectedOutput: &IFSCRedirect{
+				LegacyIfsc: "BKDN0000001",
+				Merger: &Merger{
+					Code:          "BKDN",
+					Name:          "Dena Bank",
+					SuccessorCode: "BARB",
+					SuccessorName: "Bank of Baroda",
+					EffectiveDate: "2019-04-01",
+				},
+			},
+		},
+		{
+			name: "ifsc that was never retired",
+			ifsc: "ABHY0065001",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getIFSCRedirectQuery)).WithArgs("ABHY0065001").WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(getMergerByCodeQuery)).WithArgs("ABHY").WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrRedirectNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			redirect, err := finly.GetIFSCRedirect(ctx, tc.ifsc)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, redirect)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetBankByIFSCFollowRedirect(t *testing.T) {
+	ctx := context.Background()
+	successor := &Bank{
+		Name:     "Bank of Baroda",
+		Code:     "BARB",
+		Ifsc:     "BARB0VJ1234",
+		Branch:   "MANGALORE",
+		Center:   "MANGALORE",
+		District: "DAKSHINA KANNADA",
+		State:    "KARNATAKA",
+		City:     "MANGALORE",
+		Iso3166:  "IN-KA",
+		Neft:     true,
+		Rtgs:     true,
+		Imps:     true,
+		Upi:      true,
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getIFSCRedirectQuery)).WithArgs("VIJB0001234").WillReturnRows(
+		sqlmock.NewRows([]string{"successor_ifsc"}).AddRow("BARB0VJ1234"),
+	)
+	mock.ExpectQuery(regexp.QuoteMeta(getIFSCRedirectQuery)).WithArgs("BARB0VJ1234").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta(getBankByIFSCQuery)).WithArgs("BARB0VJ1234").WillReturnRows(bankRows(successor))
+
+	finly := &Finly{
+		store: db,
+	}
+
+	bank, err := finly.GetBankByIFSC(ctx, "VIJB0001234", FollowRedirect())
+	assert.NoError(t, err)
+	assert.EqualValues(t, successor, bank)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestFollowRedirectBuiltDatabase(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Every connection to an in-memory database opens a database of its own
+	db.SetMaxOpenConns(1)
+	for _, query := range append(Schema, Indexes...) {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	for _, query := range []string{
+		`INSERT INTO bank (name, code, ifsc, branch, district, state, address, city, neft, rtgs, imps, upi)
+		VALUES ('Bank of Baroda', 'BARB', 'BARB0VJMANG', 'MANGALORE', 'DAKSHINA KANNADA', 'KARNATAKA', 'HAMPANKATTA', 'MANGALORE', 1, 1, 1, 0)`,
+		`INSERT INTO bank_merger (code, name, successor_code, successor_name, effective_date)
+		VALUES ('VIJB', 'Vijaya Bank', 'BARB', 'Bank of Baroda', '2019-04-01')`,
+		// The branch was renumbered twice, the redirects are followed to the ifsc in use
+		`INSERT INTO ifsc_redirect (legacy_ifsc, successor_ifsc) VALUES ('VIJB0001234', 'BARB0VJ1234'), ('BARB0VJ1234', 'BARB0VJMANG')`,
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	finly := &Finly{
+		store: db,
+	}
+
+	_, err = finly.GetBankByIFSC(ctx, "VIJB0001234")
+	assert.ErrorIs(t, err, ErrBankNotFound)
+
+	bank, err := finly.GetBankByIFSC(ctx, "VIJB0001234", FollowRedirect())
+	require.NoError(t, err)
+	assert.Equal(t, "BARB0VJMANG", bank.Ifsc)
+	assert.Equal(t, "MANGALORE", bank.Branch)
+	assert.True(t, bank.Neft)
+	assert.False(t, bank.Upi)
+
+	redirect, err := finly.GetIFSCRedirect(ctx, "VIJB0001234")
+	require.NoError(t, err)
+	assert.Equal(t, &IFSCRedirect{
+		LegacyIfsc:    "VIJB0001234",
+		SuccessorIfsc: "BARB0VJ1234",
+		Merger: &Merger{
+			Code:          "VIJB",
+			Name:          "Vijaya Bank",
+			SuccessorCode: "BARB",
+			SuccessorName: "Bank of Baroda",
+			EffectiveDate: "2019-04-01",
+		},
+	}, redirect)
+
+	_, err = finly.GetIFSCRedirect(ctx, "HDFC0001234")
+	assert.ErrorIs(t, err, ErrRedirectNotFound)
+}
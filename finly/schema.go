@@ -0,0 +1,167 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+// Schema holds the statements creating the tables of the finly database if they don't exist, but for the dataset
+// provenance table shared with atlas. The importer drops the tables rebuilt from every release beforehand,
+// the version and history tables are kept across releases.
+var Schema = []string{
+	// bank holds the branches of the latest release, along with the columns derived from them at import time.
+	// extras keeps the csv columns unknown to the importer as a json object keyed by their header.
+	`CREATE TABLE IF NOT EXISTS bank (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT,
+		code TEXT,
+		ifsc TEXT UNIQUE,
+		branch TEXT,
+		center TEXT,
+		district TEXT,
+		state TEXT,
+		address TEXT,
+		contact TEXT,
+		imps BOOLEAN,
+		rtgs BOOLEAN,
+		city TEXT,
+		iso3166 TEXT,
+		neft BOOLEAN,
+		micr TEXT,
+		upi BOOLEAN,
+		swift TEXT,
+		pin TEXT,
+		latitude REAL,
+		longitude REAL,
+		geo_confidence TEXT,
+		address_line TEXT,
+		address_locality TEXT,
+		address_city TEXT,
+		address_district TEXT,
+		address_state TEXT,
+		phones TEXT,
+		sponsor_code TEXT,
+		extras TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	// bank_master holds the bank level data of every bank, including the sub-member banks
+	`CREATE TABLE IF NOT EXISTS bank_master (
+		code TEXT PRIMARY KEY,
+		name TEXT,
+		ifsc TEXT,
+		micr TEXT,
+		type TEXT,
+		iin TEXT,
+		apbs BOOLEAN,
+		ach_credit BOOLEAN,
+		ach_debit BOOLEAN,
+		nach_debit BOOLEAN
+	)`,
+	// bank_merger records the banks amalgamated into another bank
+	`CREATE TABLE IF NOT EXISTS bank_merger (
+		code TEXT PRIMARY KEY,
+		name TEXT,
+		successor_code TEXT,
+		successor_name TEXT,
+		effective_date TEXT
+	)`,
+	// ifsc_redirect maps the ifscs retired by a merger to the ifscs of the successor bank
+	`CREATE TABLE IF NOT EXISTS ifsc_redirect (
+		legacy_ifsc TEXT PRIMARY KEY,
+		successor_ifsc TEXT NOT NULL
+	)`,
+	// upi_handle maps the upi handles to their psp app and sponsor bank
+	`CREATE TABLE IF NOT EXISTS upi_handle (
+		handle TEXT PRIMARY KEY,
+		psp TEXT,
+		bank_code TEXT
+	)`,
+	// version records every imported razorpay release, its id orders the releases by import
+	`CREATE TABLE IF NOT EXISTS version (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT,
+		version TEXT UNIQUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	// bank_history keeps every distinct snapshot of a branch along with the versions it was valid for
+	`CREATE TABLE IF NOT EXISTS bank_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ifsc TEXT NOT NULL,
+		name TEXT,
+		code TEXT,
+		branch TEXT,
+		center TEXT,
+		district TEXT,
+		state TEXT,
+		address TEXT,
+		contact TEXT,
+		imps BOOLEAN,
+		rtgs BOOLEAN,
+		city TEXT,
+		iso3166 TEXT,
+		neft BOOLEAN,
+		micr TEXT,
+		upi BOOLEAN,
+		swift TEXT,
+		valid_from INTEGER NOT NULL REFERENCES version (id),
+		valid_to INTEGER REFERENCES version (id),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	// bank_change records every field that changed for a branch between two versions
+	`CREATE TABLE IF NOT EXISTS bank_change (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ifsc TEXT NOT NULL,
+		version_id INTEGER NOT NULL REFERENCES version (id),
+		field TEXT NOT NULL,
+		old_value TEXT,
+		new_value TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	// ifsc_lifecycle records the first and the last version every ifsc was seen in
+	`CREATE TABLE IF NOT EXISTS ifsc_lifecycle (
+		ifsc TEXT PRIMARY KEY,
+		first_seen INTEGER NOT NULL REFERENCES version (id),
+		last_seen INTEGER NOT NULL REFERENCES version (id)
+	)`,
+}
+
+// Indexes holds the statements creating the indexes backing the lookups of the package if they don't exist.
+// The lookups by ifsc, bank code, handle and version use the primary keys and unique constraints of Schema instead.
+// The importer creates them once the data is loaded, keeping them up to date during the load would slow it down.
+var Indexes = []string{
+	// GetBranchesByMICR and the micr city code range of DecodeMICR, the city makes the latter covering
+	`CREATE INDEX IF NOT EXISTS bank_micr_idx ON bank (micr, city)`,
+	// The micr bank code fallback of DecodeMICR, the code and name make it covering
+	`CREATE INDEX IF NOT EXISTS bank_micr_bank_code_idx ON bank (substr(micr, 4, 3), code, name)`,
+	// GetBranchesBySWIFT
+	`CREATE INDEX IF NOT EXISTS bank_swift_idx ON bank (swift)`,
+	// GetBranchesBySponsor, the code and ifsc match its ordering
+	`CREATE INDEX IF NOT EXISTS bank_sponsor_code_idx ON bank (sponsor_code, code, ifsc)`,
+	// NearestBranches of a bank and the bank name fallback of the bank_master lookups
+	`CREATE INDEX IF NOT EXISTS bank_code_idx ON bank (code, latitude, longitude)`,
+	// NearestBranches of every bank
+	`CREATE INDEX IF NOT EXISTS bank_location_idx ON bank (latitude, longitude)`,
+	// The branches of a state or a district, for the consumers querying the database directly
+	`CREATE INDEX IF NOT EXISTS bank_state_district_idx ON bank (state, district)`,
+	// GetBankByIIN
+	`CREATE INDEX IF NOT EXISTS bank_master_iin_idx ON bank_master (iin)`,
+	// GetAPBSBanks, the code matches its ordering
+	`CREATE INDEX IF NOT EXISTS bank_master_apbs_idx ON bank_master (apbs, code)`,
+	// The micr bank code lookup of DecodeMICR
+	`CREATE INDEX IF NOT EXISTS bank_master_micr_bank_code_idx ON bank_master (substr(micr, 4, 3), code)`,
+	// GetBankByIFSCAsOf
+	`CREATE INDEX IF NOT EXISTS bank_history_ifsc_idx ON bank_history (ifsc, valid_from)`,
+	// The changes listed by GetBankHistory
+	`CREATE INDEX IF NOT EXISTS bank_change_ifsc_idx ON bank_change (ifsc, version_id)`,
+}
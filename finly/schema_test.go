@@ -0,0 +1,93 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexes(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Every connection to an in-memory database opens a database of its own
+	db.SetMaxOpenConns(1)
+	for _, query := range Schema {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	for _, query := range Indexes {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		{name: "bank by ifsc", query: getBankByIFSCQuery, args: []any{"SBIN0000001"}},
+		{name: "banks by swift", query: getBanksBySWIFTQuery, args: []any{"SBININBB", "SBININBB104"}},
+		{name: "banks by micr", query: getBanksByMICRQuery, args: []any{"400002001"}},
+		{name: "city by micr city code", query: getCityByMICRCityCodeQuery, args: []any{"400000000", "401000000"}},
+		{name: "bank by micr bank code", query: getBankByMICRBankCodeQuery, args: []any{"002"}},
+		{name: "bank by branch micr bank code", query: getBankByBranchMICRBankCodeQuery, args: []any{"002"}},
+		{name: "banks in bounds", query: getBanksInBoundsQuery, args: []any{18.9, 19.1, 72.7, 72.9}},
+		{name: "banks in bounds by code", query: getBanksInBoundsByCodeQuery, args: []any{"SBIN", 18.9, 19.1, 72.7, 72.9}},
+		{name: "banks by sponsor code", query: getBanksBySponsorCodeQuery, args: []any{"SBIN"}},
+		{name: "bank master by iin", query: getBankMasterByIINQuery, args: []any{"607153"}},
+		{name: "apbs bank masters", query: getAPBSBankMastersQuery},
+		{name: "version id", query: getVersionIDQuery, args: []any{"v2.0.19"}},
+		{name: "bank by ifsc as of", query: getBankByIFSCAsOfQuery, args: []any{"SBIN0000001", 1, 1}},
+		{name: "ifsc lifecycle", query: getIFSCLifecycleQuery, args: []any{"SBIN0000001"}},
+		{name: "bank changes", query: getBankChangesQuery, args: []any{"SBIN0000001"}},
+		{name: "ifsc redirect", query: getIFSCRedirectQuery, args: []any{"SBBJ0010001"}},
+		{name: "merger by code", query: getMergerByCodeQuery, args: []any{"SBBJ"}},
+		{name: "vpa handle", query: getVPAHandleQuery, args: []any{"okhdfcbank"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+tc.query, tc.args...)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			var plan []string
+			for rows.Next() {
+				var (
+					id, parent, notUsed int
+					detail              string
+				)
+				require.NoError(t, rows.Scan(&id, &parent, &notUsed, &detail))
+				plan = append(plan, detail)
+			}
+			require.NoError(t, rows.Err())
+
+			// A SCAN reads every row of the table or index, every lookup is expected to SEARCH an index instead
+			require.NotEmpty(t, plan)
+			for _, detail := range plan {
+				assert.False(t, strings.HasPrefix(detail, "SCAN"), "full scan in %q", plan)
+			}
+		})
+	}
+}
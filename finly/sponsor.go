@@ -0,0 +1,41 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"strings"
+)
+
+// getBanksBySponsorCodeQuery is the query to get the branches of the sub-member banks clearing through a sponsor bank
+const getBanksBySponsorCodeQuery = `SELECT ` + bankColumns + `
+FROM bank WHERE sponsor_code = ? ORDER BY code, ifsc`
+
+// IsSubMember reports whether the bank is a sub-member bank clearing through a sponsor bank,
+// in which case its ifsc carries the sponsor's code instead of its own
+func (b *Bank) IsSubMember() bool {
+	return b.SponsorCode != ""
+}
+
+// GetBranchesBySponsor returns the branches of the sub-member banks clearing through the sponsor bank with the code.
+// It returns ErrBankNotFound if the bank sponsors no sub-member bank.
+func (b *Finly) GetBranchesBySponsor(ctx context.Context, sponsorCode string) ([]*Bank, error) {
+	// A database built by an older importer doesn't record the sponsor banks
+	if b.missing["sponsor_code"] {
+		return nil, ErrBankNotFound
+	}
+
+	return b.queryBanks(ctx, getBanksBySponsorCodeQuery, strings.ToUpper(strings.TrimSpace(sponsorCode)))
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBranchesBySponsor(t *testing.T) {
+	ctx := context.Background()
+	subMember := &Bank{
+		Name:        "The Ahmedabad District Co-operative Bank",
+		Code:        "ADBX",
+		Ifsc:        "YESB0ADB002",
+		Branch:      "NARANPURA",
+		Neft:        true,
+		Imps:        true,
+		SponsorCode: "YESB",
+		SponsorName: "Yes Bank",
+	}
+
+	testCases := []struct {
+		expectedError  error
+		expectedOutput []*Bank
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		sponsorCode    string
+	}{
+		{
+			name:        "sponsor bank",
+			sponsorCode: " yesb",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksBySponsorCodeQuery)).WithArgs("YESB").WillReturnRows(bankRows(subMember))
+			},
+			expectedOutput: []*Bank{subMember},
+		},
+		{
+			name:        "bank sponsoring no sub-member",
+			sponsorCode: "ABHY",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getBanksBySponsorCodeQuery)).WithArgs("ABHY").WillReturnRows(bankRows())
+			},
+			expectedError: ErrBankNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			banks, err := finly.GetBranchesBySponsor(ctx, tc.sponsorCode)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, banks)
+				assert.True(t, banks[0].IsSubMember())
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"strings"
+)
+
+// states maps the normalized names of the indian states and union territories to their ISO 3166-2 codes.
+// It includes the former names still found in the dataset.
+var states = map[string]string{
+	"ANDAMAN AND NICOBAR ISLANDS": "IN-AN",
+	"ANDAMAN AND NICOBAR ISLAND":  "IN-AN",
+	"ANDHRA PRADESH":              "IN-AP",
+	"ARUNACHAL PRADESH":           "IN-AR",
+	"ASSAM":                       "IN-AS",
+	"BIHAR":                       "IN-BR",
+	"CHANDIGARH":                  "IN-CH",
+	"CHHATTISGARH":                "IN-CT",
+	"THE DADRA AND NAGAR HAVELI AND DAMAN AND DIU": "IN-DH",
+	"DADRA AND NAGAR HAVELI AND DAMAN AND DIU":     "IN-DH",
+	"DADRA AND NAGAR HAVELI":                       "IN-DH",
+	"DAMAN AND DIU":                                "IN-DH",
+	"DELHI":                                        "IN-DL",
+	"NEW DELHI":                                    "IN-DL",
+	"NCT OF DELHI":                                 "IN-DL",
+	"GOA":                                          "IN-GA",
+	"GUJARAT":                                      "IN-GJ",
+	"HARYANA":                                      "IN-HR",
+	"HIMACHAL PRADESH":                             "IN-HP",
+	"JAMMU AND KASHMIR":                            "IN-JK",
+	"JHARKHAND":                                    "IN-JH",
+	"KARNATAKA":                                    "IN-KA",
+	"KERALA":                                       "IN-KL",
+	"LADAKH":                                       "IN-LA",
+	"LAKSHADWEEP":                                  "IN-LD",
+	"MADHYA PRADESH":                               "IN-MP",
+	"MAHARASHTRA":                                  "IN-MH",
+	"MANIPUR":                                      "IN-MN",
+	"MEGHALAYA":                                    "IN-ML",
+	"MIZORAM":                                      "IN-MZ",
+	"NAGALAND":                                     "IN-NL",
+	"ODISHA":                                       "IN-OR",
+	"ORISSA":                                       "IN-OR",
+	"PUDUCHERRY":                                   "IN-PY",
+	"PONDICHERRY":                                  "IN-PY",
+	"PUNJAB":                                       "IN-PB",
+	"RAJASTHAN":                                    "IN-RJ",
+	"SIKKIM":                                       "IN-SK",
+	"TAMIL NADU":                                   "IN-TN",
+	"TELANGANA":                                    "IN-TG",
+	"TRIPURA":                                      "IN-TR",
+	"UTTAR PRADESH":                                "IN-UP",
+	"UTTARAKHAND":                                  "IN-UT",
+	"UTTARANCHAL":                                  "IN-UT",
+	"WEST BENGAL":                                  "IN-WB",
+}
+
+// stateCodeAliases maps the ISO 3166-2 codes changed in 2023 to the codes used by the dataset
+var stateCodeAliases = map[string]string{
+	"IN-CG": "IN-CT",
+	"IN-OD": "IN-OR",
+	"IN-TS": "IN-TG",
+	"IN-UK": "IN-UT",
+}
+
+// StateCode returns the ISO 3166-2 code of an indian state or union territory.
+// It accepts either the name of the state, as found in Bank.State, or its code, as found in Bank.Iso3166.
+// It returns false if the state is unknown.
+func StateCode(state string) (string, bool) {
+	name := normalizeStateName(state)
+	if code, ok := states[name]; ok {
+		return code, true
+	}
+
+	if alias, ok := stateCodeAliases[name]; ok {
+		return alias, true
+	}
+
+	for _, code := range states {
+		if code == name {
+			return code, true
+		}
+	}
+
+	return "", false
+}
+
+// normalizeStateName upper cases the name, spells out ampersands and collapses the whitespace
+func normalizeStateName(name string) string {
+	name = strings.ToUpper(name)
+	name = strings.ReplaceAll(name, "&", " AND ")
+
+	return strings.Join(strings.Fields(name), " ")
+}
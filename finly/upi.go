@@ -0,0 +1,95 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// getVPAHandleQuery is the query to get the psp and the sponsor bank of a upi handle
+const getVPAHandleQuery = `SELECT h.handle, h.psp, h.bank_code,
+COALESCE(NULLIF(m.name, ''), (SELECT name FROM bank WHERE code = h.bank_code LIMIT 1), '')
+FROM upi_handle h LEFT JOIN bank_master m ON m.code = h.bank_code
+WHERE h.handle = ?`
+
+var (
+	// vpaPattern matches a upi virtual payment address, e.g. jane.doe@okhdfcbank
+	vpaPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{1,255}@[a-zA-Z][a-zA-Z0-9]{1,63}$`)
+	// handlePattern matches a upi handle without the leading @
+	handlePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]{1,63}$`)
+)
+
+var ErrInvalidVPA = errors.New("invalid vpa")
+
+var ErrVPAHandleNotFound = errors.New("vpa handle not found")
+
+// VPAHandle represents the handle of a upi virtual payment address, i.e. the part after the @
+type VPAHandle struct {
+	// Handle specifies the handle without the leading @, e.g. okhdfcbank
+	Handle string `json:"handle"`
+	// PSP specifies the payment service provider app that issues the handle, e.g. Google Pay
+	PSP string `json:"psp"`
+	// BankCode specifies the code of the sponsor bank backing the handle
+	BankCode string `json:"bank_code"`
+	// BankName specifies the name of the sponsor bank backing the handle
+	BankName string `json:"bank_name"`
+}
+
+// IsValidVPA reports whether vpa is a syntactically valid upi virtual payment address, e.g. jane.doe@okhdfcbank
+func IsValidVPA(vpa string) bool {
+	return vpaPattern.MatchString(strings.TrimSpace(vpa))
+}
+
+// ResolveVPAHandle returns the psp app and the sponsor bank of the handle of a vpa.
+// It accepts a full vpa such as jane.doe@okhdfcbank, or a bare handle with or without its leading @.
+// It returns ErrInvalidVPA if the vpa is malformed and ErrVPAHandleNotFound if the handle is not in the registry.
+func (b *Finly) ResolveVPAHandle(ctx context.Context, vpa string) (*VPAHandle, error) {
+	vpa = strings.TrimSpace(vpa)
+
+	var handle string
+	switch at := strings.LastIndexByte(vpa, '@'); {
+	case at > 0:
+		if !IsValidVPA(vpa) {
+			return nil, ErrInvalidVPA
+		}
+		handle = vpa[at+1:]
+	default:
+		handle = strings.TrimPrefix(vpa, "@")
+		if !handlePattern.MatchString(handle) {
+			return nil, ErrInvalidVPA
+		}
+	}
+
+	var h VPAHandle
+	err := b.store.QueryRowContext(ctx, getVPAHandleQuery, strings.ToLower(handle)).Scan(
+		&h.Handle,
+		&h.PSP,
+		&h.BankCode,
+		&h.BankName,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVPAHandleNotFound
+		}
+
+		return nil, err
+	}
+
+	return &h, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finly
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidVPA(t *testing.T) {
+	testCases := []struct {
+		vpa      string
+		expected bool
+	}{
+		{vpa: "jane.doe@okhdfcbank", expected: true},
+		{vpa: "9876543210@ybl", expected: true},
+		{vpa: " jane_doe-1@paytm ", expected: true},
+		{vpa: "jane.doe", expected: false},
+		{vpa: "j@ybl", expected: false},
+		{vpa: ".jane@ybl", expected: false},
+		{vpa: "jane@ok.hdfc", expected: false},
+		{vpa: "jane@1bl", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.vpa, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsValidVPA(tc.vpa))
+		})
+	}
+}
+
+func TestResolveVPAHandle(t *testing.T) {
+	ctx := context.Background()
+	handleRowColumns := []string{"handle", "psp", "bank_code", "bank_name"}
+
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *VPAHandle
+		mockDB         func(mock sqlmock.Sqlmock)
+		name           string
+		vpa            string
+	}{
+		{
+			name: "full vpa",
+			vpa:  "Jane.Doe@OKHDFCBANK",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getVPAHandleQuery)).WithArgs("okhdfcbank").WillReturnRows(
+					sqlmock.NewRows(handleRowColumns).AddRow("okhdfcbank", "Google Pay", "HDFC", "HDFC Bank"),
+				)
+			},
+			expectedOutput: &VPAHandle{Handle: "okhdfcbank", PSP: "Google Pay", BankCode: "HDFC", BankName: "HDFC Bank"},
+		},
+		{
+			name: "bare handle",
+			vpa:  "@ybl",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getVPAHandleQuery)).WithArgs("ybl").WillReturnRows(
+					sqlmock.NewRows(handleRowColumns).AddRow("ybl", "PhonePe", "YESB", "Yes Bank"),
+				)
+			},
+			expectedOutput: &VPAHandle{Handle: "ybl", PSP: "PhonePe", BankCode: "YESB", BankName: "Yes Bank"},
+		},
+		{
+			name: "unknown handle",
+			vpa:  "jane@unknownbank",
+			mockDB: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(getVPAHandleQuery)).WithArgs("unknownbank").WillReturnError(sql.ErrNoRows)
+			},
+			expectedError: ErrVPAHandleNotFound,
+		},
+		{
+			name:          "invalid vpa",
+			vpa:           "jane@ok hdfc",
+			mockDB:        func(mock sqlmock.Sqlmock) {},
+			expectedError: ErrInvalidVPA,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			tc.mockDB(mock)
+
+			finly := &Finly{
+				store: db,
+			}
+
+			handle, err := finly.ResolveVPAHandle(ctx, tc.vpa)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.EqualValues(t, tc.expectedOutput, handle)
+			}
+
+			err = mock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dataset reads the provenance of the datasets served by finly and atlas,
+// which their importers record in the dataset table.
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// latestQuery is the query to get the provenance of the latest imported dataset
+const latestQuery = `SELECT source, version, checksum, row_counts, imported_at
+FROM dataset ORDER BY id DESC LIMIT 1`
+
+// tableExistsQuery is the query to check whether the database has a dataset table,
+// the importers that predate it built databases without one
+const tableExistsQuery = `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'dataset')`
+
+var ErrNotFound = errors.New("dataset info not found")
+
+// Info represents the provenance of a dataset, the packages serving the datasets convert it to their own type
+type Info struct {
+	Source     string           `json:"source"`
+	Version    string           `json:"version"`
+	ImportedAt time.Time        `json:"imported_at"`
+	RowCounts  map[string]int64 `json:"row_counts"`
+	Checksum   string           `json:"checksum"`
+}
+
+// Latest returns the provenance of the latest dataset imported into the database.
+// It returns ErrNotFound if the dataset was imported without recording its provenance,
+// including by an importer that predates the dataset table.
+func Latest(ctx context.Context, db *sql.DB) (*Info, error) {
+	var (
+		info       Info
+		rowCounts  string
+		importedAt string
+	)
+	err := db.QueryRowContext(ctx, latestQuery).Scan(
+		&info.Source,
+		&info.Version,
+		&info.Checksum,
+		&rowCounts,
+		&importedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		// The query fails on the databases without a dataset table, any other failure is reported as is
+		var exists bool
+		if existsErr := db.QueryRowContext(ctx, tableExistsQuery).Scan(&exists); existsErr == nil && !exists {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(rowCounts), &info.RowCounts)
+	if err != nil {
+		return nil, err
+	}
+
+	info.ImportedAt, err = time.Parse(time.RFC3339, importedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
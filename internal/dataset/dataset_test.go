@@ -0,0 +1,96 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite" // Import the sqlite driver
+)
+
+func TestLatest(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError  error
+		expectedOutput *Info
+		name           string
+		queries        []string
+		failure        bool
+	}{
+		{
+			name:          "database without dataset table",
+			expectedError: ErrNotFound,
+		},
+		{
+			name:          "dataset without provenance",
+			queries:       []string{`CREATE TABLE dataset (id INTEGER PRIMARY KEY, source TEXT, version TEXT, checksum TEXT, row_counts TEXT, imported_at TEXT)`},
+			expectedError: ErrNotFound,
+		},
+		{
+			name: "latest dataset",
+			queries: []string{
+				`CREATE TABLE dataset (id INTEGER PRIMARY KEY, source TEXT, version TEXT, checksum TEXT, row_counts TEXT, imported_at TEXT)`,
+				`INSERT INTO dataset VALUES (1, 'IFSC.csv', 'v2.0.19', 'sha256:01', '{"bank":1}', '2023-10-23T10:15:00Z')`,
+				`INSERT INTO dataset VALUES (2, 'IFSC.csv', 'v2.0.20', 'sha256:02', '{"bank":2}', '2023-11-23T10:15:00Z')`,
+			},
+			expectedOutput: &Info{
+				Source:     "IFSC.csv",
+				Version:    "v2.0.20",
+				ImportedAt: time.Date(2023, time.November, 23, 10, 15, 0, 0, time.UTC),
+				RowCounts:  map[string]int64{"bank": 2},
+				Checksum:   "sha256:02",
+			},
+		},
+		{
+			name:    "dataset table of another shape",
+			queries: []string{`CREATE TABLE dataset (id INTEGER PRIMARY KEY, name TEXT)`},
+			failure: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := sql.Open("sqlite", ":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			// Every connection to an in-memory database opens a database of its own
+			db.SetMaxOpenConns(1)
+
+			for _, query := range tc.queries {
+				_, err = db.ExecContext(ctx, query)
+				require.NoError(t, err)
+			}
+
+			info, err := Latest(ctx, db)
+			switch {
+			case tc.failure:
+				// The failures other than a missing table are reported as is
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNotFound)
+			case tc.expectedError != nil:
+				assert.ErrorIs(t, err, tc.expectedError)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedOutput, info)
+			}
+		})
+	}
+}
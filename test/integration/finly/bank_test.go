@@ -23,6 +23,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+const (
+	mumbaiLatitude  = 19.07
+	mumbaiLongitude = 72.88
+	// mumbaiRadius is the distance in degrees from the center of Mumbai the coordinates of its branches fall within
+	mumbaiRadius = 0.5
+)
+
 func TestGetBankByIFSC(t *testing.T) {
 	ctx := context.Background()
 	testCases := []struct {
@@ -53,6 +60,16 @@ func TestGetBankByIFSC(t *testing.T) {
 				Rtgs:     true,
 				Imps:     true,
 				Upi:      true,
+				Pin:      "400024",
+				StructuredAddress: finly.BranchAddress{
+					Line:     "Abhyudaya Building, Kamal Nath Marg, Nehru Nagar",
+					Locality: "Kurla-East",
+					City:     "Mumbai",
+					District: "Mumbai",
+					State:    "Maharashtra",
+					PIN:      "400024",
+				},
+				Phones: []finly.PhoneNumber{{Number: "+919653261383", Type: finly.PhoneMobile}},
 			},
 		},
 		{
@@ -64,16 +81,27 @@ func TestGetBankByIFSC(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			finly, err := finly.New()
+			store, err := finly.New()
 			if err != nil {
 				require.NoError(t, err)
 			}
 
-			bank, err := finly.GetBankByIFSC(ctx, tc.ifsc)
+			bank, err := store.GetBankByIFSC(ctx, tc.ifsc)
 			if tc.expectedError != nil {
 				assert.EqualError(t, err, tc.expectedError.Error())
 			} else {
-				assert.NoError(t, err)
+				require.NoError(t, err)
+
+				// The coordinates come from the geonames postal codes of atlas, the pin of the branch is in Mumbai.
+				// A database built before the branches were geocoded has none.
+				if bank.GeoConfidence != finly.GeoConfidenceNone {
+					assert.Equal(t, finly.GeoConfidenceHigh, bank.GeoConfidence)
+					assert.InDelta(t, mumbaiLatitude, bank.Latitude, mumbaiRadius)
+					assert.InDelta(t, mumbaiLongitude, bank.Longitude, mumbaiRadius)
+				}
+				tc.expectedOutput.Latitude, tc.expectedOutput.Longitude = bank.Latitude, bank.Longitude
+				tc.expectedOutput.GeoConfidence = bank.GeoConfidence
+
 				assert.EqualValues(t, tc.expectedOutput, bank)
 			}
 		})
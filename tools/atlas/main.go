@@ -15,21 +15,29 @@
 package main
 
 import (
-	"archive/zip"
 	"context"
-	"database/sql"
-	"encoding/csv"
+	"crypto/sha256"
+	"flag"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
-
-	_ "github.com/libsql/libsql-client-go/libsql"
-	_ "modernc.org/sqlite"
+	"time"
+
+	"github.com/imumesh18/bifrost/atlas"
+	"github.com/imumesh18/bifrost/tools/internal/dataset"
+	"github.com/imumesh18/bifrost/tools/internal/diff"
+	"github.com/imumesh18/bifrost/tools/internal/download"
+	"github.com/imumesh18/bifrost/tools/internal/quarantine"
+	"github.com/imumesh18/bifrost/tools/internal/rebuild"
+	"github.com/imumesh18/bifrost/tools/internal/report"
 )
 
+// atlasDB is the path of the database built by the importer
+const atlasDB = "./atlas/data/atlas.db"
+
 // GeoLocation represents a geographical location details
 type GeoLocation struct {
 	// ISO country code abbreviation
@@ -72,197 +80,250 @@ type GeoLocation struct {
 //nolint:funlen,gocyclo
 func main() {
 	ctx := context.Background()
-	// Download the allCountries.zip file
-	url := "http://download.geonames.org/export/zip/allCountries.zip"
-	req, err := http.NewRequestWithContext(context.TODO(), "GET", url, http.NoBody)
+	startedAt := time.Now()
+
+	// The postal codes of all countries are downloaded from geonames unless local files or countries are given
+	var paths []string
+	flag.Func("file", "local geonames postal code file, a zip archive such as IN.zip or the extracted txt file, may be repeated",
+		func(path string) error {
+			paths = append(paths, path)
+			return nil
+		})
+	countryList := flag.String("countries", "", "comma separated iso codes of the countries to keep, e.g. IN,US")
+	maxRejectRate := flag.Float64("max-reject-rate", quarantine.DefaultMaxRejectRate,
+		"share of invalid rows quarantined before the import fails, e.g. 0.01 for 1%")
+	cacheDir := flag.String("cache-dir", download.DefaultCacheDir("atlas"), "directory the postal code files are downloaded into")
+	reportPath := flag.String("report", "-", "path of the json report of the run, - for the standard output")
+	flag.Parse()
+
+	// Report the outcome of the run whichever way it ends, err holds the reason of a failure
+	var err error
+	rep := report.New("atlas", startedAt)
+	defer func() {
+		if writeErr := rep.Write(*reportPath, err); writeErr != nil {
+			slog.WarnContext(ctx, "error writing report", slog.Any("err", writeErr), slog.String("file", *reportPath))
+		}
+	}()
+
+	countries, err := parseCountries(*countryList)
 	if err != nil {
-		slog.ErrorContext(ctx, "error creating request", slog.Any("err", err), slog.String("url", url))
+		slog.ErrorContext(ctx, "error parsing countries", slog.Any("err", err), slog.String("countries", *countryList))
 		return
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	var files []*postalCodeFile
+	if len(paths) > 0 {
+		for _, path := range paths {
+			var file *postalCodeFile
+			file, err = localPostalCodeFile(path)
+			if err != nil {
+				slog.ErrorContext(ctx, "error opening file", slog.Any("err", err), slog.String("file", path))
+				return
+			}
+			files = append(files, file)
+		}
+	} else {
+		// Only the files of the kept countries are downloaded, they are a fraction of the size of allCountries.zip
+		urls := []string{allCountriesURL()}
+		if len(countries) > 0 {
+			urls = urls[:0]
+			for country := range countries {
+				urls = append(urls, countryURL(country))
+			}
+			sort.Strings(urls)
+		}
+
+		client := download.New(*cacheDir)
+		for _, url := range urls {
+			var file *postalCodeFile
+			file, err = downloadPostalCodeFile(ctx, client, url)
+			if err != nil {
+				slog.ErrorContext(ctx, "error downloading file", slog.Any("err", err), slog.String("url", url))
+				return
+			}
+			files = append(files, file)
+		}
+	}
+
+	// Checksum the files to record which files the dataset was built from, each file is reported with its own checksum
+	checksum := sha256.New()
+	for _, file := range files {
+		fileChecksum := sha256.New()
+		err = checksumFile(io.MultiWriter(checksum, fileChecksum), file.Path)
+		if err != nil {
+			slog.ErrorContext(ctx, "error reading file", slog.Any("err", err), slog.String("file", file.Location))
+			return
+		}
+		rep.Sources = append(rep.Sources, report.Source{URL: file.Location, Checksum: dataset.Checksum(fileChecksum)})
+	}
+
+	// Rebuild the SQLite3 database from an empty one, the database is only replaced once the import succeeds.
+	// Every table but the provenance of the previous imports is rebuilt from the postal codes.
+	build, err := rebuild.StartEmpty(ctx, atlasDB, "dataset")
 	if err != nil {
-		slog.ErrorContext(ctx, "error making request", slog.Any("err", err), slog.String("url", url))
+		slog.ErrorContext(ctx, "error opening database", slog.Any("err", err), slog.String("file", atlasDB))
 		return
 	}
-	defer resp.Body.Close()
+	defer build.Abort()
 
-	// Create a temporary file to store the downloaded ZIP archive
-	tmpFile, err := os.CreateTemp("", "allCountries-*.zip")
+	// The temporary database is discarded if the import fails, it is loaded without the journal and the syncs to disk
+	err = build.BulkLoad(ctx)
 	if err != nil {
-		slog.ErrorContext(ctx, "error creating temporary file", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error tuning database", slog.Any("err", err))
 		return
 	}
-	defer os.Remove(tmpFile.Name())
-	defer tmpFile.Close()
 
-	// Write the ZIP archive to the temporary file
-	_, err = io.Copy(tmpFile, resp.Body)
+	// Loop through the files and insert their postal codes into the database
+	tx, err := build.DB.Begin()
 	if err != nil {
-		slog.ErrorContext(ctx, "error writing to temporary file", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error beginning transaction", slog.Any("err", err))
 		return
 	}
+	defer tx.Rollback() //nolint:errcheck // a no-op once the transaction is committed
 
-	// Extract the allCountries.txt file from the ZIP archive
-	zipReader, err := zip.OpenReader(tmpFile.Name())
+	// Recreate the geo_location table, so that re-running the import never duplicates the postal codes
+	_, err = tx.Exec(`DROP TABLE IF EXISTS geo_location`)
 	if err != nil {
-		slog.ErrorContext(ctx, "error opening ZIP archive", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error dropping table", slog.Any("err", err))
 		return
 	}
-	defer zipReader.Close()
 
-	var allCountriesFile *zip.File
-	for _, file := range zipReader.File {
-		if file.Name == "allCountries.txt" {
-			allCountriesFile = file
-			break
+	for _, query := range atlas.Schema {
+		_, err = tx.Exec(query)
+		if err != nil {
+			slog.ErrorContext(ctx, "error creating table", slog.Any("err", err))
+			return
 		}
 	}
-	if allCountriesFile == nil {
-		slog.ErrorContext(ctx, "allCountries.txt not found in ZIP archive")
+
+	// The postal codes are inserted many rows a statement, the indexes are only created once they are all loaded
+	stmt, err := tx.Prepare(insertQuery())
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing statement", slog.Any("err", err))
 		return
 	}
+	defer stmt.Close()
 
-	allCountriesReader, err := allCountriesFile.Open()
+	var (
+		sources    []string
+		modifiedAt time.Time
+	)
+	// Invalid postal codes are quarantined instead of failing the import, unless there are too many of them
+	q, err := quarantine.New(ctx, tx)
 	if err != nil {
-		slog.ErrorContext(ctx, "error opening allCountries.txt", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error creating quarantine", slog.Any("err", err))
 		return
 	}
-	defer allCountriesReader.Close()
+	defer q.Close()
+
+	for _, file := range files {
+		err = importPostalCodes(ctx, stmt, q, file, countries)
+		if err != nil {
+			slog.ErrorContext(ctx, "error importing file", slog.Any("err", err), slog.String("file", file.Location))
+			return
+		}
+
+		sources = append(sources, file.Location)
+		if file.ModifiedAt.After(modifiedAt) {
+			modifiedAt = file.ModifiedAt
+		}
+	}
 
-	// Open the SQLite3 database
-	db, err := sql.Open("libsql", "file:./atlas/data/atlas.db")
+	q.LogSummary(ctx)
+	rep.Rejected = q.Reasons
+	err = q.Check(*maxRejectRate)
 	if err != nil {
-		slog.ErrorContext(ctx, "error opening database", slog.Any("err", err))
+		slog.ErrorContext(ctx, "too many invalid rows", slog.Any("err", err))
 		return
 	}
-	defer db.Close()
-
-	// Create the geonames table if it doesn't exist
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS geo_location (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-        country_code TEXT,
-        postal_code TEXT,
-        place_name TEXT,
-        admin_name1 TEXT,
-        admin_code1 TEXT,
-        admin_name2 TEXT,
-        admin_code2 TEXT,
-        admin_name3 TEXT,
-        admin_code3 TEXT,
-        latitude REAL,
-        longitude REAL,
-        accuracy INTEGER,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-        updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    )`)
+
+	// Index the loaded postal codes for the lookups of the atlas package
+	for _, query := range atlas.Indexes {
+		_, err = tx.Exec(query)
+		if err != nil {
+			slog.ErrorContext(ctx, "error creating index", slog.Any("err", err))
+			return
+		}
+	}
+
+	// Record the provenance of the imported dataset
+	err = dataset.CreateTable(ctx, tx)
 	if err != nil {
 		slog.ErrorContext(ctx, "error creating table", slog.Any("err", err))
 		return
 	}
 
-	// Parse the allCountries.txt file as a CSV
-	reader := csv.NewReader(allCountriesReader)
-	reader.Comma = '\t'
-	reader.LazyQuotes = true
+	// geonames publishes no version, the latest modification time of the files stands in for one
+	var version string
+	if !modifiedAt.IsZero() {
+		version = modifiedAt.UTC().Format(http.TimeFormat)
+	}
 
-	// Loop through the CSV records and insert them into the database
-	tx, err := db.Begin()
+	rep.Version = version
+	rep.PreviousVersion, err = dataset.LatestVersion(ctx, tx)
 	if err != nil {
-		slog.ErrorContext(ctx, "error beginning transaction", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error reading previous version", slog.Any("err", err))
 		return
 	}
-	stmt, err := tx.Prepare(`INSERT INTO geo_location (
-        country_code,
-        postal_code,
-        place_name,
-        admin_name1,
-        admin_code1,
-        admin_name2,
-        admin_code2,
-        admin_name3,
-        admin_code3,
-        latitude,
-        longitude,
-        accuracy
-    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+
+	info := &dataset.Info{
+		Source:     strings.Join(sources, " "),
+		Version:    version,
+		Checksum:   dataset.Checksum(checksum),
+		ImportedAt: startedAt,
+	}
+	info.RowCounts, err = dataset.CountRows(ctx, tx, "geo_location", "quarantine")
 	if err != nil {
-		slog.ErrorContext(ctx, "error preparing statement", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error counting rows", slog.Any("err", err))
 		return
 	}
-	defer stmt.Close()
 
-	var record []string
-	for {
-		record, err = reader.Read()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			slog.ErrorContext(ctx, "error reading csv", slog.Any("err", err))
-			return
-		}
-
-		// Parse the record into a GeoName struct
-		geoName := GeoLocation{
-			CountryCode: record[0],
-			PostalCode:  record[1],
-			PlaceName:   record[2],
-			AdminName1:  record[3],
-			AdminCode1:  record[4],
-			AdminName2:  record[5],
-			AdminCode2:  record[6],
-			AdminName3:  record[7],
-			AdminCode3:  record[8],
-		}
+	err = dataset.Record(ctx, tx, info)
+	if err != nil {
+		slog.ErrorContext(ctx, "error recording dataset", slog.Any("err", err))
+		return
+	}
+	rep.RowCounts = info.RowCounts
 
-		// Parse the latitude and longitude fields as floats
-		geoName.Latitude, err = strconv.ParseFloat(record[9], 64)
-		if err != nil {
-			slog.ErrorContext(ctx, "error parsing latitude", slog.Any("err", err))
-			return
-		}
-		geoName.Longitude, err = strconv.ParseFloat(record[10], 64)
-		if err != nil {
-			slog.ErrorContext(ctx, "error parsing longitude", slog.Any("err", err))
-			return
-		}
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "error committing transaction", slog.Any("err", err))
+		return
+	}
 
-		// Parse the accuracy field as an integer
-		if strings.TrimSpace(record[11]) != "" {
-			geoName.Accuracy, err = strconv.Atoi(strings.TrimSpace(record[11]))
-		}
-		if err != nil {
-			slog.ErrorContext(ctx, "error parsing accuracy", slog.Any("err", err))
-			return
-		}
+	// Compare the rebuilt table with the database about to be replaced
+	rep.Changes, err = diff.CountAll(ctx, build.DB, atlasDB, diff.AtlasTables)
+	if err != nil {
+		slog.ErrorContext(ctx, "error comparing with the previous database", slog.Any("err", err), slog.String("file", atlasDB))
+		return
+	}
 
-		// Insert the GeoName struct into the database
-		_, err = stmt.Exec(
-			geoName.CountryCode,
-			geoName.PostalCode,
-			geoName.PlaceName,
-			geoName.AdminName1,
-			geoName.AdminCode1,
-			geoName.AdminName2,
-			geoName.AdminCode2,
-			geoName.AdminName3,
-			geoName.AdminCode3,
-			geoName.Latitude,
-			geoName.Longitude,
-			geoName.Accuracy,
-		)
-		if err != nil {
-			slog.ErrorContext(ctx, "error executing statement", slog.Any("err", err))
-			return
-		}
+	err = build.Optimize(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error optimizing database", slog.Any("err", err))
+		return
 	}
 
-	err = tx.Commit()
+	// Swap in the rebuilt database, keeping the previous one as a rollback copy
+	err = build.Commit(ctx, rebuild.NotEmpty("geo_location"))
 	if err != nil {
-		slog.ErrorContext(ctx, "error committing transaction", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error replacing database", slog.Any("err", err), slog.String("file", atlasDB))
 		return
 	}
 
+	rep.Status = report.StatusUpdated
 	slog.InfoContext(ctx, "data generated successfully")
 }
+
+// checksumFile writes the content of the file at path to the checksum
+func checksumFile(checksum io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(checksum, f)
+
+	return err
+}
@@ -0,0 +1,271 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/imumesh18/bifrost/tools/internal/progress"
+	"github.com/imumesh18/bifrost/tools/internal/quarantine"
+)
+
+const (
+	// insertBatchSize is the number of rows of a batch, the valid postal codes of a batch are inserted by a single statement
+	insertBatchSize = 1000
+	// pipelineDepth is the number of parsed batches buffered ahead of the writer
+	pipelineDepth = 8
+)
+
+// geoLocationColumns are the columns of geo_location inserted from a geonames row, in the order of geoLocationValues
+var geoLocationColumns = []string{
+	"country_code",
+	"postal_code",
+	"place_name",
+	"admin_name1",
+	"admin_code1",
+	"admin_name2",
+	"admin_code2",
+	"admin_name3",
+	"admin_code3",
+	"latitude",
+	"longitude",
+	"accuracy",
+}
+
+// rejectedRow is a row of a geonames file quarantined along with the reason
+type rejectedRow struct {
+	line   int
+	record []string
+	reason error
+}
+
+// batch is a batch of parsed rows of a geonames file
+type batch struct {
+	// rows are the valid postal codes encoded as a json array of arrays of the values of geoLocationColumns
+	rows []byte
+	// accepted is the number of valid postal codes
+	accepted int64
+	// rejected are the invalid rows
+	rejected []rejectedRow
+}
+
+// importPostalCodes inserts the postal codes of the file, skipping those of the countries not kept.
+// The invalid postal codes are quarantined.
+// The file is parsed in a goroutine of its own, the rows are handed over to the writer in batches.
+func importPostalCodes(ctx context.Context, stmt *sql.Stmt, q *quarantine.Quarantine, file *postalCodeFile, countries map[string]bool) error {
+	r, size, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	bar := progress.New(os.Stderr, path.Base(file.Location), size)
+	defer bar.Finish()
+
+	// The parser stops once the writer fails, the context is then canceled
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan *batch, pipelineDepth)
+	parseErr := make(chan error, 1)
+	go func() {
+		defer close(batches)
+		parseErr <- parsePostalCodes(ctx, bar.Reader(r), countries, batches)
+	}()
+
+	// Once a write fails the batches parsed in the meantime are dropped until the parser stops,
+	// so that it no longer reads from the file once this returns
+	for b := range batches {
+		if err != nil {
+			continue
+		}
+		err = writeBatch(ctx, stmt, q, file, b)
+		if err != nil {
+			cancel()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return <-parseErr
+}
+
+// parsePostalCodes parses the tab separated postal codes of r and sends them to batches, skipping those of the countries not kept
+func parsePostalCodes(ctx context.Context, r io.Reader, countries map[string]bool, batches chan<- *batch) error {
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	reader.LazyQuotes = true
+
+	kept := func(countryCode string) bool {
+		return len(countries) == 0 || countries[countryCode]
+	}
+
+	b := &batch{}
+	send := func() error {
+		if b.accepted > 0 {
+			b.rows = append(b.rows, ']')
+		}
+
+		select {
+		case batches <- b:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		b = &batch{}
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var (
+			line     int
+			reason   error
+			csvError *csv.ParseError
+			geoName  *GeoLocation
+		)
+		switch {
+		case errors.As(err, &csvError):
+			if len(record) > 0 && !kept(record[0]) {
+				continue
+			}
+			line, reason = csvError.StartLine, quarantine.ErrMalformedRow
+		case err != nil:
+			return err
+		default:
+			if !kept(record[0]) {
+				continue
+			}
+			line, _ = reader.FieldPos(0)
+			geoName, reason = parseGeoLocation(record)
+		}
+
+		if reason != nil {
+			b.rejected = append(b.rejected, rejectedRow{line: line, record: record, reason: reason})
+		} else {
+			separator := byte(',')
+			if b.accepted == 0 {
+				separator = '['
+			}
+			b.rows = appendGeoLocation(append(b.rows, separator), geoName)
+			b.accepted++
+		}
+
+		if int(b.accepted)+len(b.rejected) == insertBatchSize {
+			err = send()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if b.accepted == 0 && len(b.rejected) == 0 {
+		return nil
+	}
+
+	return send()
+}
+
+// writeBatch inserts the valid postal codes of the batch and quarantines the invalid ones
+func writeBatch(ctx context.Context, stmt *sql.Stmt, q *quarantine.Quarantine, file *postalCodeFile, b *batch) error {
+	if b.accepted > 0 {
+		_, err := stmt.ExecContext(ctx, string(b.rows))
+		if err != nil {
+			return err
+		}
+		q.AcceptN(b.accepted)
+	}
+
+	for _, row := range b.rejected {
+		err := q.Reject(ctx, file.Location, row.line, row.record, row.reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendGeoLocation appends the values of geoLocationColumns of the postal code to buf as a json array.
+// The values are encoded by hand, encoding/json spends more time on reflection than sqlite spends inserting them.
+func appendGeoLocation(buf []byte, geoName *GeoLocation) []byte {
+	buf = append(buf, '[')
+	for _, value := range []string{
+		geoName.CountryCode,
+		geoName.PostalCode,
+		geoName.PlaceName,
+		geoName.AdminName1,
+		geoName.AdminCode1,
+		geoName.AdminName2,
+		geoName.AdminCode2,
+		geoName.AdminName3,
+		geoName.AdminCode3,
+	} {
+		buf = append(appendJSONString(buf, value), ',')
+	}
+	buf = append(strconv.AppendFloat(buf, geoName.Latitude, 'g', -1, 64), ',')
+	buf = append(strconv.AppendFloat(buf, geoName.Longitude, 'g', -1, 64), ',')
+	buf = strconv.AppendInt(buf, int64(geoName.Accuracy), 10)
+
+	return append(buf, ']')
+}
+
+// appendJSONString appends s to buf as a json string, the strings needing escapes are left to encoding/json
+func appendJSONString(buf []byte, s string) []byte {
+	escape := !utf8.ValidString(s)
+	for i := 0; i < len(s) && !escape; i++ {
+		escape = s[i] < ' ' || s[i] == '"' || s[i] == '\\'
+	}
+	if escape {
+		// Marshaling a string never fails
+		encoded, _ := json.Marshal(s)
+		return append(buf, encoded...)
+	}
+
+	buf = append(buf, '"')
+	buf = append(buf, s...)
+
+	return append(buf, '"')
+}
+
+// insertQuery returns the query inserting a batch of postal codes into geo_location.
+// The batch is bound as a single json array rather than a parameter per value, the sqlite driver
+// parses the query on every execution and binds the parameters in quadratic time, both of which
+// outweigh the cost of the json for the hundreds of rows of a batch.
+func insertQuery() string {
+	values := make([]string, 0, len(geoLocationColumns))
+	for i := range geoLocationColumns {
+		values = append(values, fmt.Sprintf("value ->> %d", i))
+	}
+
+	return `INSERT INTO geo_location (` + strings.Join(geoLocationColumns, ", ") + `)
+	SELECT ` + strings.Join(values, ", ") + ` FROM json_each(?)`
+}
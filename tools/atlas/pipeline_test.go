@@ -0,0 +1,270 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imumesh18/bifrost/atlas"
+	"github.com/imumesh18/bifrost/tools/internal/quarantine"
+	_ "modernc.org/sqlite" // Import the sqlite driver
+)
+
+// postalCodes returns n valid geonames rows of the country, one a line
+func postalCodes(country string, n int) string {
+	record := strings.Split(koramangala, "\t")
+	record[0] = country
+
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteString(strings.Join(record, "\t"))
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// collectBatches parses the postal codes of content and returns the batches sent
+func collectBatches(t *testing.T, content string, countries map[string]bool) []*batch {
+	t.Helper()
+
+	batches := make(chan *batch, pipelineDepth)
+	parseErr := make(chan error, 1)
+	go func() {
+		defer close(batches)
+		parseErr <- parsePostalCodes(context.Background(), strings.NewReader(content), countries, batches)
+	}()
+
+	var collected []*batch
+	for b := range batches {
+		collected = append(collected, b)
+	}
+	require.NoError(t, <-parseErr)
+
+	return collected
+}
+
+func TestParsePostalCodes(t *testing.T) {
+	invalid := strings.Replace(koramangala, "560095", "56009", 1) + "\n"
+
+	testCases := []struct {
+		countries        map[string]bool
+		name             string
+		content          string
+		expectedAccepted []int64
+		expectedRejected []int
+	}{
+		{
+			name:             "empty file",
+			content:          "",
+			expectedAccepted: nil,
+			expectedRejected: nil,
+		},
+		{
+			name:             "single batch",
+			content:          postalCodes("IN", 2) + invalid,
+			expectedAccepted: []int64{2},
+			expectedRejected: []int{1},
+		},
+		{
+			name:             "full batches",
+			content:          postalCodes("IN", insertBatchSize-1) + invalid + postalCodes("IN", insertBatchSize+1),
+			expectedAccepted: []int64{insertBatchSize - 1, insertBatchSize, 1},
+			expectedRejected: []int{1, 0, 0},
+		},
+		{
+			name:             "countries not kept",
+			countries:        map[string]bool{"IN": true},
+			content:          postalCodes("US", insertBatchSize) + postalCodes("IN", 1) + "US\t\"bad\n",
+			expectedAccepted: []int64{1},
+			expectedRejected: []int{0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				accepted []int64
+				rejected []int
+			)
+			for _, b := range collectBatches(t, tc.content, tc.countries) {
+				accepted = append(accepted, b.accepted)
+				rejected = append(rejected, len(b.rejected))
+
+				// The valid postal codes of a batch are a json array of as many rows
+				if b.accepted > 0 {
+					var rows [][]any
+					require.NoError(t, json.Unmarshal(b.rows, &rows))
+					assert.Len(t, rows, int(b.accepted))
+				}
+			}
+
+			assert.Equal(t, tc.expectedAccepted, accepted)
+			assert.Equal(t, tc.expectedRejected, rejected)
+		})
+	}
+}
+
+func TestParsePostalCodesRejectedLine(t *testing.T) {
+	invalid := strings.Replace(koramangala, "560095", "56009", 1) + "\n"
+
+	batches := collectBatches(t, postalCodes("IN", 2)+invalid+"IN\t\"bad\n", nil)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0].rejected, 2)
+
+	assert.Equal(t, 3, batches[0].rejected[0].line)
+	assert.Equal(t, errInvalidPIN, batches[0].rejected[0].reason)
+	assert.Equal(t, 4, batches[0].rejected[1].line)
+	assert.Equal(t, quarantine.ErrMalformedRow, batches[0].rejected[1].reason)
+}
+
+func TestParsePostalCodesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Nothing reads the batches, the parser stops on the canceled context instead of blocking
+	err := parsePostalCodes(ctx, strings.NewReader(postalCodes("IN", 1)), nil, make(chan *batch))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAppendGeoLocation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		placeName string
+	}{
+		{name: "plain", placeName: "Koramangala VI Bk"},
+		{name: "unicode", placeName: "Bengaluru ಬೆಂಗಳೂರು"},
+		{name: "quote and backslash", placeName: `Koramangala "VI" Bk\2`},
+		{name: "control character", placeName: "Koramangala\tVI\x01Bk"},
+		{name: "invalid utf-8", placeName: "Koramangala \xff"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			geoLocation := &GeoLocation{
+				CountryCode: "IN",
+				PostalCode:  "560095",
+				PlaceName:   tc.placeName,
+				Latitude:    12.9343,
+				Longitude:   -77.6227,
+				Accuracy:    4,
+			}
+
+			var values []any
+			require.NoError(t, json.Unmarshal(appendGeoLocation(nil, geoLocation), &values))
+			require.Len(t, values, len(geoLocationColumns))
+
+			// encoding/json replaces invalid utf-8 with the replacement character
+			assert.Equal(t, strings.ToValidUTF8(tc.placeName, "�"), values[2])
+			assert.Equal(t, "IN", values[0])
+			assert.InDelta(t, 12.9343, values[9], 1e-9)
+			assert.InDelta(t, -77.6227, values[10], 1e-9)
+			assert.InDelta(t, 4, values[11], 0)
+		})
+	}
+}
+
+// newImport returns the statement inserting the postal codes and the quarantine of a transaction on an in-memory database
+func newImport(t *testing.T) (*sql.Tx, *sql.Stmt, *quarantine.Quarantine) {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	// Every connection to an in-memory database opens a database of its own
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { tx.Rollback() }) //nolint:errcheck // a no-op once the transaction is committed
+
+	for _, query := range atlas.Schema {
+		_, err = tx.Exec(query)
+		require.NoError(t, err)
+	}
+
+	stmt, err := tx.Prepare(insertQuery())
+	require.NoError(t, err)
+
+	q, err := quarantine.New(ctx, tx)
+	require.NoError(t, err)
+	t.Cleanup(func() { q.Close() })
+
+	return tx, stmt, q
+}
+
+// writePostalCodeFile writes the content to a txt file and returns it
+func writePostalCodeFile(t *testing.T, content string) *postalCodeFile {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "IN.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	file, err := localPostalCodeFile(path)
+	require.NoError(t, err)
+
+	return file
+}
+
+func TestImportPostalCodes(t *testing.T) {
+	ctx := context.Background()
+	tx, stmt, q := newImport(t)
+	defer stmt.Close()
+
+	invalid := strings.Replace(koramangala, "560095", "56009", 1) + "\n"
+	file := writePostalCodeFile(t, postalCodes("IN", insertBatchSize)+invalid+postalCodes("IN", 2)+postalCodes("US", 3))
+
+	err := importPostalCodes(ctx, stmt, q, file, map[string]bool{"IN": true})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(insertBatchSize+2), q.Accepted)
+	assert.Equal(t, int64(1), q.Rejected)
+	assert.Equal(t, map[string]int64{errInvalidPIN.Error(): 1}, q.Reasons)
+
+	var count int
+	require.NoError(t, tx.QueryRow(`SELECT COUNT(*) FROM geo_location WHERE country_code = 'IN' AND postal_code = '560095'`).Scan(&count))
+	assert.Equal(t, insertBatchSize+2, count)
+
+	var (
+		source string
+		line   int
+	)
+	require.NoError(t, tx.QueryRow(`SELECT source, line FROM quarantine`).Scan(&source, &line))
+	assert.Equal(t, file.Location, source)
+	assert.Equal(t, insertBatchSize+1, line)
+}
+
+func TestImportPostalCodesWriteError(t *testing.T) {
+	ctx := context.Background()
+	_, stmt, q := newImport(t)
+
+	// A closed statement fails every write, the parser must stop rather than block on the full pipeline
+	require.NoError(t, stmt.Close())
+	file := writePostalCodeFile(t, postalCodes("IN", (pipelineDepth+2)*insertBatchSize))
+
+	err := importPostalCodes(ctx, stmt, q, file, nil)
+	assert.EqualError(t, err, "sql: statement is closed")
+	assert.Zero(t, q.Accepted)
+}
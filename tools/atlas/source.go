@@ -0,0 +1,164 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/imumesh18/bifrost/tools/internal/download"
+)
+
+// geonamesURL is the base url of the geonames postal code files, the tests point it at a local server
+var geonamesURL = "http://download.geonames.org/export/zip"
+
+const (
+	// readmeFile is the readme geonames bundles with the postal codes in every archive
+	readmeFile = "readme.txt"
+	// countryCodeLength is the length of an iso 3166-1 alpha-2 country code
+	countryCodeLength = 2
+)
+
+var errPostalCodesNotFound = errors.New("postal codes not found in zip archive")
+
+// postalCodeFile is a geonames postal code file, either a zip archive as published or the txt file extracted from it
+type postalCodeFile struct {
+	// Location is where the file was read from, it is recorded as the provenance of the dataset
+	Location string
+	// Path is the local path of the file
+	Path string
+	// ModifiedAt is when the file was last modified, geonames publishes no version so it stands in for one
+	ModifiedAt time.Time
+}
+
+// allCountriesURL returns the url of the geonames postal codes of all countries
+func allCountriesURL() string {
+	return geonamesURL + "/allCountries.zip"
+}
+
+// countryURL returns the url of the geonames postal codes of a single country by its iso code, e.g. IN.zip
+func countryURL(country string) string {
+	return geonamesURL + "/" + country + ".zip"
+}
+
+// localPostalCodeFile returns the postal code file at the local path
+func localPostalCodeFile(path string) (*postalCodeFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := filepath.Abs(path)
+	if err != nil {
+		location = path
+	}
+
+	return &postalCodeFile{Location: "file://" + location, Path: path, ModifiedAt: info.ModTime()}, nil
+}
+
+// downloadPostalCodeFile downloads the postal code file at url into the cache directory of the client,
+// unless the cached file is current
+func downloadPostalCodeFile(ctx context.Context, client *download.Client, url string) (*postalCodeFile, error) {
+	file, err := client.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// A file served without a modification time is recorded without a version
+	return &postalCodeFile{Location: url, Path: file.Path, ModifiedAt: file.LastModified}, nil
+}
+
+// Open returns the tab separated postal codes of the file along with their size, extracting them from the zip archive if need be
+func (f *postalCodeFile) Open() (io.ReadCloser, int64, error) {
+	if !strings.EqualFold(filepath.Ext(f.Path), ".zip") {
+		return openFile(f.Path)
+	}
+
+	zipReader, err := zip.OpenReader(f.Path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The archives hold the postal codes in a txt file named after them, e.g. IN.txt in IN.zip, next to the readme
+	for _, file := range zipReader.File {
+		if !strings.EqualFold(filepath.Ext(file.Name), ".txt") || strings.EqualFold(file.Name, readmeFile) {
+			continue
+		}
+
+		var r io.ReadCloser
+		r, err = file.Open()
+		if err != nil {
+			zipReader.Close()
+			return nil, 0, err
+		}
+
+		return &zipFileReader{ReadCloser: r, archive: zipReader}, int64(file.UncompressedSize64), nil
+	}
+
+	zipReader.Close()
+
+	return nil, 0, fmt.Errorf("%w: %s", errPostalCodesNotFound, f.Location)
+}
+
+// openFile opens the file at path and returns its size
+func openFile(path string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	return f, info.Size(), nil
+}
+
+// zipFileReader reads a file extracted from a zip archive, closing the archive along with the file
+type zipFileReader struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+// Close closes the file and its archive
+func (r *zipFileReader) Close() error {
+	return errors.Join(r.ReadCloser.Close(), r.archive.Close())
+}
+
+// parseCountries returns the set of the comma separated iso country codes, an empty set keeps every country
+func parseCountries(list string) (map[string]bool, error) {
+	countries := make(map[string]bool)
+	for _, code := range strings.Split(list, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+		if len(code) != countryCodeLength || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("invalid country code %q", code)
+		}
+		countries[code] = true
+	}
+
+	return countries, nil
+}
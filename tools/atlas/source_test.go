@@ -0,0 +1,90 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imumesh18/bifrost/tools/internal/download"
+)
+
+func TestDownloadPostalCodeFile(t *testing.T) {
+	ctx := context.Background()
+	modifiedAt := time.Date(2023, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	// A stand-in for geonames serving the postal codes of India, the base url points at it for the duration of the test
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/export/zip/IN.zip":
+			w.Header().Set("Last-Modified", modifiedAt.Format(http.TimeFormat))
+			io.WriteString(w, koramangala+"\n") //nolint:errcheck // the test fails on the client side if the write does
+		case "/export/zip/allCountries.zip":
+			io.WriteString(w, koramangala+"\n") //nolint:errcheck // the test fails on the client side if the write does
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	baseURL := geonamesURL
+	geonamesURL = server.URL + "/export/zip"
+	defer func() { geonamesURL = baseURL }()
+
+	testCases := []struct {
+		expectedModifiedAt time.Time
+		name               string
+		url                string
+		expectedError      string
+	}{
+		{
+			name:               "country",
+			url:                countryURL("IN"),
+			expectedModifiedAt: modifiedAt,
+		},
+		{
+			name: "all countries without modification time",
+			url:  allCountriesURL(),
+		},
+		{
+			name:          "unknown country",
+			url:           countryURL("ZZ"),
+			expectedError: "unexpected status 404 Not Found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := downloadPostalCodeFile(ctx, download.New(t.TempDir()), tc.url)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				assert.Nil(t, file)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.url, file.Location)
+			assert.True(t, tc.expectedModifiedAt.Equal(file.ModifiedAt), file.ModifiedAt)
+			assert.FileExists(t, file.Path)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// geonamesFields is the number of tab separated fields of a geonames postal code
+	geonamesFields = 12
+	// maxLatitude is the largest latitude in degrees, the smallest is its opposite
+	maxLatitude = 90
+	// maxLongitude is the largest longitude in degrees, the smallest is its opposite
+	maxLongitude = 180
+)
+
+// pinPattern matches an indian pin code, geonames lists the indian postal codes under IN
+var pinPattern = regexp.MustCompile(`^[1-9][0-9]{5}$`)
+
+var (
+	errFieldCount       = errors.New("unexpected number of fields")
+	errMissingValue     = errors.New("missing value")
+	errInvalidCountry   = errors.New("invalid country code")
+	errInvalidPIN       = errors.New("invalid pin code")
+	errInvalidLatitude  = errors.New("invalid latitude")
+	errInvalidLongitude = errors.New("invalid longitude")
+	errInvalidAccuracy  = errors.New("invalid accuracy")
+)
+
+// parseGeoLocation parses and validates a geonames postal code.
+// The errors name the offending field but not its value, they are counted by message in the import summary.
+func parseGeoLocation(record []string) (*GeoLocation, error) {
+	if len(record) != geonamesFields {
+		return nil, errFieldCount
+	}
+
+	// Parse the record into a GeoName struct
+	geoName := GeoLocation{
+		CountryCode: record[0],
+		PostalCode:  strings.TrimSpace(record[1]),
+		PlaceName:   record[2],
+		AdminName1:  record[3],
+		AdminCode1:  record[4],
+		AdminName2:  record[5],
+		AdminCode2:  record[6],
+		AdminName3:  record[7],
+		AdminCode3:  record[8],
+	}
+
+	if len(geoName.CountryCode) != countryCodeLength || strings.Trim(geoName.CountryCode, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return nil, errInvalidCountry
+	}
+	if geoName.PostalCode == "" {
+		return nil, fmt.Errorf("%w: postal_code", errMissingValue)
+	}
+	if geoName.CountryCode == "IN" && !pinPattern.MatchString(geoName.PostalCode) {
+		return nil, errInvalidPIN
+	}
+	if strings.TrimSpace(geoName.PlaceName) == "" {
+		return nil, fmt.Errorf("%w: place_name", errMissingValue)
+	}
+
+	// Parse the latitude and longitude fields as floats, ParseFloat accepts NaN and Inf which json can't encode
+	var err error
+	geoName.Latitude, err = strconv.ParseFloat(strings.TrimSpace(record[9]), 64)
+	if err != nil || !isCoordinate(geoName.Latitude, maxLatitude) {
+		return nil, errInvalidLatitude
+	}
+	geoName.Longitude, err = strconv.ParseFloat(strings.TrimSpace(record[10]), 64)
+	if err != nil || !isCoordinate(geoName.Longitude, maxLongitude) {
+		return nil, errInvalidLongitude
+	}
+
+	// Parse the accuracy field as an integer, it is left out for some postal codes
+	if accuracy := strings.TrimSpace(record[11]); accuracy != "" {
+		geoName.Accuracy, err = strconv.Atoi(accuracy)
+		if err != nil || geoName.Accuracy < 0 {
+			return nil, errInvalidAccuracy
+		}
+	}
+
+	return &geoName, nil
+}
+
+// isCoordinate reports whether the degrees are a finite number between -limit and limit
+func isCoordinate(degrees, limit float64) bool {
+	return !math.IsNaN(degrees) && !math.IsInf(degrees, 0) && degrees >= -limit && degrees <= limit
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// koramangala is a valid geonames postal code, the test cases change one of its fields
+const koramangala = "IN\t560095\tKoramangala VI Bk\tKarnataka\t19\tBangalore\t583\tBangalore South\t\t12.9343\t77.6227\t4"
+
+func TestParseGeoLocation(t *testing.T) {
+	testCases := []struct {
+		expectedError error
+		name          string
+		field         int
+		value         string
+	}{
+		{name: "valid", field: 0, value: "IN"},
+		{name: "postal code with spaces", field: 1, value: " 560095 "},
+		{name: "other country", field: 0, value: "US"},
+		{name: "no accuracy", field: 11, value: ""},
+		{name: "lowercase country", field: 0, value: "in", expectedError: errInvalidCountry},
+		{name: "long country", field: 0, value: "IND", expectedError: errInvalidCountry},
+		{name: "missing postal code", field: 1, value: " ", expectedError: fmt.Errorf("%w: postal_code", errMissingValue)},
+		{name: "short pin", field: 1, value: "56009", expectedError: errInvalidPIN},
+		{name: "pin starting with 0", field: 1, value: "060095", expectedError: errInvalidPIN},
+		{name: "missing place name", field: 2, value: "", expectedError: fmt.Errorf("%w: place_name", errMissingValue)},
+		{name: "latitude out of range", field: 9, value: "90.5", expectedError: errInvalidLatitude},
+		{name: "latitude not a number", field: 9, value: "north", expectedError: errInvalidLatitude},
+		{name: "nan latitude", field: 9, value: "NaN", expectedError: errInvalidLatitude},
+		{name: "infinite latitude", field: 9, value: "-Inf", expectedError: errInvalidLatitude},
+		{name: "longitude out of range", field: 10, value: "-180.5", expectedError: errInvalidLongitude},
+		{name: "nan longitude", field: 10, value: "nan", expectedError: errInvalidLongitude},
+		{name: "infinite longitude", field: 10, value: "+Inf", expectedError: errInvalidLongitude},
+		{name: "negative accuracy", field: 11, value: "-1", expectedError: errInvalidAccuracy},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := strings.Split(koramangala, "\t")
+			record[tc.field] = tc.value
+
+			geoLocation, err := parseGeoLocation(record)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+				assert.Nil(t, geoLocation)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(record[1]), geoLocation.PostalCode)
+			assert.Equal(t, "Koramangala VI Bk", geoLocation.PlaceName)
+			assert.InDelta(t, 12.9343, geoLocation.Latitude, 1e-9)
+			assert.InDelta(t, 77.6227, geoLocation.Longitude, 1e-9)
+		})
+	}
+}
+
+func TestParseGeoLocationFieldCount(t *testing.T) {
+	record := strings.Split(koramangala, "\t")
+
+	_, err := parseGeoLocation(record[:geonamesFields-1])
+	assert.ErrorIs(t, err, errFieldCount)
+
+	_, err = parseGeoLocation(append(record, "extra"))
+	assert.ErrorIs(t, err, errFieldCount)
+}
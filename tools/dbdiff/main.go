@@ -0,0 +1,234 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command dbdiff compares two versions of a finly or atlas database and reports the ifscs or postal codes
+// added, removed and changed between them, along with the fields that changed, as text or json.
+// The kind of the databases is told apart by their tables.
+//
+// Usage:
+//
+//	go run ./tools/dbdiff -old finly.db.bak -new finly/data/finly.db
+//
+// The databases are stored with git lfs, a previous version is checked out by smudging its pointer:
+//
+//	git show HEAD~1:finly/data/finly.db | git lfs smudge > /tmp/finly.db
+//	go run ./tools/dbdiff -old /tmp/finly.db -new finly/data/finly.db -format json
+//
+// Both databases are opened read-only.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	_ "github.com/libsql/libsql-client-go/libsql"
+	_ "modernc.org/sqlite"
+
+	"github.com/imumesh18/bifrost/tools/internal/diff"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// config holds the command line flags
+type config struct {
+	oldPath string
+	newPath string
+	out     string
+	format  string
+}
+
+// tableDiff is the difference between the two versions of a table
+type tableDiff struct {
+	// Name is the name of the table
+	Name string `json:"name"`
+	// Counts is the number of rows added, removed and changed
+	Counts diff.Counts `json:"counts"`
+	// Changes are the rows added, removed and changed, ordered by key
+	Changes []diff.Change `json:"changes"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	var cfg config
+	flag.StringVar(&cfg.oldPath, "old", "", "path of the previous version of the database")
+	flag.StringVar(&cfg.newPath, "new", "", "path of the current version of the database")
+	flag.StringVar(&cfg.out, "out", "-", "file the differences are written to, - for stdout")
+	flag.StringVar(&cfg.format, "format", formatText, "format of the differences, text or json")
+	flag.Parse()
+
+	if err := run(ctx, &cfg); err != nil {
+		slog.ErrorContext(ctx, "error comparing databases", slog.Any("err", err))
+		os.Exit(1)
+	}
+}
+
+// run compares the databases and writes their differences
+func run(ctx context.Context, cfg *config) error {
+	if cfg.oldPath == "" || cfg.newPath == "" {
+		return fmt.Errorf("both -old and -new are required")
+	}
+	if cfg.format != formatText && cfg.format != formatJSON {
+		return fmt.Errorf("unsupported format %q", cfg.format)
+	}
+
+	// Opening a missing database would create an empty one, both must exist
+	for _, path := range []string{cfg.oldPath, cfg.newPath} {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	}
+
+	uri, err := diff.ReadOnlyURI(cfg.newPath)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("libsql", uri)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	c, err := diff.Open(ctx, db, cfg.oldPath)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tables, err := c.Tables(ctx)
+	if err != nil {
+		return err
+	}
+
+	diffs := make([]tableDiff, 0, len(tables))
+	for _, t := range tables {
+		var changes []diff.Change
+		changes, err = c.Changes(ctx, t)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", t.Name, err)
+		}
+		diffs = append(diffs, newTableDiff(t.Name, changes))
+	}
+
+	out := os.Stdout
+	if cfg.out != "-" {
+		var f *os.File
+		f, err = os.Create(cfg.out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if cfg.format == formatJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Tables []tableDiff `json:"tables"`
+		}{diffs})
+	}
+
+	return writeText(out, diffs)
+}
+
+// newTableDiff returns the difference of the table made of the changes
+func newTableDiff(name string, changes []diff.Change) tableDiff {
+	d := tableDiff{Name: name, Changes: changes}
+	if d.Changes == nil {
+		d.Changes = []diff.Change{}
+	}
+
+	for _, change := range changes {
+		switch change.Kind {
+		case diff.KindAdded:
+			d.Counts.Added++
+		case diff.KindRemoved:
+			d.Counts.Removed++
+		case diff.KindChanged:
+			d.Counts.Changed++
+		}
+	}
+
+	return d
+}
+
+// changeMarkers prefix the changes of the text format, as in a unified diff
+var changeMarkers = map[diff.Kind]string{diff.KindAdded: "+", diff.KindRemoved: "-", diff.KindChanged: "~"}
+
+// writeText writes the differences as text, a summary line per table followed by a line per change.
+// The changed fields are indented under the key of their row.
+func writeText(w io.Writer, diffs []tableDiff) error {
+	for _, d := range diffs {
+		_, err := fmt.Fprintf(w, "%s: %d added, %d removed, %d changed\n", d.Name, d.Counts.Added, d.Counts.Removed, d.Counts.Changed)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range d.Changes {
+			_, err = fmt.Fprintf(w, "%s %s\n", changeMarkers[change.Kind], formatKey(change.Key))
+			if err != nil {
+				return err
+			}
+
+			for _, field := range change.Fields {
+				_, err = fmt.Fprintf(w, "    %s: %s -> %s\n", field.Column, formatValue(field.Previous), formatValue(field.Current))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatKey formats the key of a row as column=value pairs sorted by column
+func formatKey(key map[string]string) string {
+	columns := make([]string, 0, len(key))
+	for column := range key {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	pairs := make([]string, 0, len(columns))
+	for _, column := range columns {
+		pairs = append(pairs, column+"="+key[column])
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// formatValue formats the value of a field, quoting text so that empty and null values tell apart
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
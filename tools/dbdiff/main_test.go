@@ -0,0 +1,134 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imumesh18/bifrost/tools/internal/diff"
+)
+
+// createDB creates a finly database at path holding the branches
+func createDB(t *testing.T, path string, branches ...[2]string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE bank (id INTEGER PRIMARY KEY, ifsc TEXT, city TEXT)`)
+	require.NoError(t, err)
+	for _, b := range branches {
+		_, err = db.Exec(`INSERT INTO bank (ifsc, city) VALUES (?, NULLIF(?, ''))`, b[0], b[1])
+		require.NoError(t, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.db"), filepath.Join(dir, "new.db")
+	createDB(t, oldPath, [2]string{"SBIN0000001", "KOLKATA"}, [2]string{"SBIN0000002", ""}, [2]string{"SBIN0000003", "HOWRAH"})
+	createDB(t, newPath, [2]string{"SBIN0000001", "KOLKATA"}, [2]string{"SBIN0000002", "KOLKATA"}, [2]string{"SBIN0000004", "DURGAPUR"})
+
+	oldContent, err := os.ReadFile(oldPath)
+	require.NoError(t, err)
+	newContent, err := os.ReadFile(newPath)
+	require.NoError(t, err)
+
+	t.Run("text", func(t *testing.T) {
+		out := filepath.Join(dir, "diff.txt")
+		require.NoError(t, run(ctx, &config{oldPath: oldPath, newPath: newPath, out: out, format: formatText}))
+
+		text, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, `bank: 1 added, 1 removed, 1 changed
++ ifsc=SBIN0000004
+- ifsc=SBIN0000003
+~ ifsc=SBIN0000002
+    city: null -> "KOLKATA"
+bank_master: 0 added, 0 removed, 0 changed
+bank_merger: 0 added, 0 removed, 0 changed
+ifsc_redirect: 0 added, 0 removed, 0 changed
+upi_handle: 0 added, 0 removed, 0 changed
+`, string(text))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out := filepath.Join(dir, "diff.json")
+		require.NoError(t, run(ctx, &config{oldPath: oldPath, newPath: newPath, out: out, format: formatJSON}))
+
+		content, err := os.ReadFile(out)
+		require.NoError(t, err)
+		var report struct {
+			Tables []tableDiff `json:"tables"`
+		}
+		require.NoError(t, json.Unmarshal(content, &report))
+		require.Len(t, report.Tables, len(diff.FinlyTables))
+		assert.Equal(t, "bank", report.Tables[0].Name)
+		assert.Equal(t, diff.Counts{Added: 1, Removed: 1, Changed: 1}, report.Tables[0].Counts)
+		assert.Len(t, report.Tables[0].Changes, 3)
+		assert.Equal(t, []diff.Change{}, report.Tables[1].Changes)
+	})
+
+	// Both databases are opened read-only
+	content, err := os.ReadFile(oldPath)
+	require.NoError(t, err)
+	assert.Equal(t, oldContent, content)
+	content, err = os.ReadFile(newPath)
+	require.NoError(t, err)
+	assert.Equal(t, newContent, content)
+}
+
+func TestRunErrors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "finly.db")
+	createDB(t, path)
+
+	testCases := []struct {
+		name string
+		cfg  config
+	}{
+		{name: "missing old database flag", cfg: config{newPath: path, out: "-", format: formatText}},
+		{name: "unsupported format", cfg: config{oldPath: path, newPath: path, out: "-", format: "yaml"}},
+		{name: "missing database", cfg: config{oldPath: filepath.Join(dir, "missing.db"), newPath: path, out: "-", format: formatText}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, run(ctx, &tc.cfg))
+		})
+	}
+
+	// Opening a missing database would have created it
+	_, err := os.Stat(filepath.Join(dir, "missing.db"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "null", formatValue(nil))
+	assert.Equal(t, `""`, formatValue(""))
+	assert.Equal(t, `"KOLKATA"`, formatValue("KOLKATA"))
+	assert.Equal(t, "12.5", formatValue(12.5))
+	assert.Equal(t, "ifsc=SBIN0000001 state=WB", formatKey(map[string]string{"state": "WB", "ifsc": "SBIN0000001"}))
+}
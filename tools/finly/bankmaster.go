@@ -0,0 +1,77 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+)
+
+// importBankMaster loads the banks of banks.json into the bank_master table,
+// along with the names of every bank including the sub-member banks missing from banks.json
+func importBankMaster(ctx context.Context, tx *sql.Tx, banks map[string]BankCode, names map[string]string) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO bank_master (
+		code,
+		name,
+		ifsc,
+		micr,
+		type,
+		iin,
+		apbs,
+		ach_credit,
+		ach_debit,
+		nach_debit
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	seen := make(map[string]bool)
+	for code, bank := range banks {
+		if bank.Code != "" {
+			code = bank.Code
+		}
+		seen[code] = true
+
+		_, err = stmt.ExecContext(ctx, code, names[code], bank.Ifsc, bank.Micr, bank.Type,
+			nullIfEmpty(bank.Iin), bank.Apbs, bank.AchCredit, bank.AchDebit, bank.NachDebit)
+		if err != nil {
+			return err
+		}
+	}
+
+	for code, name := range names {
+		if seen[code] {
+			continue
+		}
+
+		_, err = stmt.ExecContext(ctx, code, name, nil, nil, nil, nil, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nullIfEmpty returns nil for an empty string so that it is stored as NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
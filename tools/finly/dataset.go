@@ -0,0 +1,88 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/imumesh18/bifrost/tools/internal/dataset"
+	"github.com/imumesh18/bifrost/tools/internal/report"
+)
+
+// datasetTables are the tables whose row counts are recorded with the provenance of the dataset
+var datasetTables = []string{"bank", "bank_master", "bank_merger", "ifsc_redirect", "upi_handle", "bank_history", "bank_change", "quarantine"}
+
+// localFiles are the files maintained by hand in the repository the dataset is built from along with the release files
+var localFiles = []string{mergersFile, redirectsFile, localSubletFile, upiHandlesFile}
+
+// recordDataset records the provenance and the row counts of the imported dataset
+func recordDataset(ctx context.Context, tx *sql.Tx, info *dataset.Info) error {
+	err := dataset.CreateTable(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	info.RowCounts, err = dataset.CountRows(ctx, tx, datasetTables...)
+	if err != nil {
+		return err
+	}
+
+	return dataset.Record(ctx, tx, info)
+}
+
+// localSources returns the local files the dataset is built from along with their checksum, skipping the missing ones
+func localSources() ([]report.Source, error) {
+	sources := make([]report.Source, 0, len(localFiles))
+	for _, path := range localFiles {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		checksum := sha256.New()
+		_, err = io.Copy(checksum, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := filepath.Abs(path)
+		if err != nil {
+			location = path
+		}
+		sources = append(sources, report.Source{URL: "file://" + location, Checksum: dataset.Checksum(checksum)})
+	}
+
+	return sources, nil
+}
+
+// datasetChecksum returns the checksum of a dataset built from the sources, the checksum of their checksums in order.
+// It only depends on the content of the sources, the same files downloaded or read locally have the same checksum.
+func datasetChecksum(sources []report.Source) string {
+	checksum := sha256.New()
+	for _, src := range sources {
+		io.WriteString(checksum, src.Checksum+"\n") //nolint:errcheck // writing to a hash never fails
+	}
+
+	return dataset.Checksum(checksum)
+}
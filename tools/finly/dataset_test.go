@@ -0,0 +1,59 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imumesh18/bifrost/tools/internal/report"
+)
+
+func TestLocalSources(t *testing.T) {
+	chdirRepository(t)
+
+	sources, err := localSources()
+	require.NoError(t, err)
+
+	// Every file maintained by hand is shipped with the repository
+	require.Len(t, sources, len(localFiles))
+	for i, src := range sources {
+		assert.True(t, strings.HasSuffix(src.URL, strings.TrimPrefix(localFiles[i], ".")), src.URL)
+		assert.True(t, strings.HasPrefix(src.Checksum, "sha256:"), src.Checksum)
+	}
+}
+
+func TestDatasetChecksum(t *testing.T) {
+	sources := []report.Source{
+		{URL: "https://github.com/razorpay/ifsc/releases/download/v2.0.20/IFSC.csv", Checksum: "sha256:01"},
+		{URL: "file:///bifrost/tools/finly/data/mergers.json", Checksum: "sha256:02"},
+	}
+	checksum := datasetChecksum(sources)
+	assert.True(t, strings.HasPrefix(checksum, "sha256:"), checksum)
+
+	// The same files read from elsewhere make the same dataset
+	local := []report.Source{
+		{URL: "file:///ifsc/IFSC.csv", Checksum: "sha256:01"},
+		{URL: "file:///bifrost/tools/finly/data/mergers.json", Checksum: "sha256:02"},
+	}
+	assert.Equal(t, checksum, datasetChecksum(local))
+
+	// Any change of the files maintained by hand changes the dataset
+	changed := []report.Source{sources[0], {URL: sources[1].URL, Checksum: "sha256:03"}}
+	assert.NotEqual(t, checksum, datasetChecksum(changed))
+}
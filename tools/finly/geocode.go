@@ -0,0 +1,133 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/imumesh18/bifrost/finly"
+)
+
+// atlasDB is the atlas database the branches are geocoded against
+const atlasDB = "file:./atlas/data/atlas.db?mode=ro"
+
+// geoPoint represents the coordinates of a pin code or a district
+type geoPoint struct {
+	latitude  float64
+	longitude float64
+	// state is the ISO 3166-2 code of the state the point lies in
+	state string
+}
+
+// geocoder resolves the addresses of the branches to coordinates using the indian postal codes of atlas
+type geocoder struct {
+	// pins maps the pin codes to the centroid of their places
+	pins map[string]geoPoint
+	// districts maps the state code and the upper cased district name to the centroid of the district
+	districts map[string]geoPoint
+}
+
+// loadGeocoder loads the indian pin codes and district centroids from the atlas database
+func loadGeocoder(ctx context.Context, dsn string) (*geocoder, error) {
+	db, err := sql.Open("libsql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	g := &geocoder{
+		pins:      make(map[string]geoPoint),
+		districts: make(map[string]geoPoint),
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT postal_code, AVG(latitude), AVG(longitude), MAX(admin_name1)
+	FROM geo_location WHERE country_code = 'IN' GROUP BY postal_code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			pin, state string
+			point      geoPoint
+		)
+		err = rows.Scan(&pin, &point.latitude, &point.longitude, &state)
+		if err != nil {
+			return nil, err
+		}
+		point.state, _ = finly.StateCode(state)
+		g.pins[pin] = point
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	districts, err := db.QueryContext(ctx, `SELECT admin_name1, UPPER(admin_name2), AVG(latitude), AVG(longitude)
+	FROM geo_location WHERE country_code = 'IN' GROUP BY admin_name1, UPPER(admin_name2)`)
+	if err != nil {
+		return nil, err
+	}
+	defer districts.Close()
+
+	for districts.Next() {
+		var (
+			state, district string
+			point           geoPoint
+		)
+		err = districts.Scan(&state, &district, &point.latitude, &point.longitude)
+		if err != nil {
+			return nil, err
+		}
+		// The districts of the states that don't resolve would share their keys across states
+		var ok bool
+		point.state, ok = finly.StateCode(state)
+		if !ok {
+			continue
+		}
+		g.districts[point.state+"|"+district] = point
+	}
+
+	return g, districts.Err()
+}
+
+// geocode returns the pin code of the address, the coordinates of the branch and how reliable they are.
+// The coordinates are nil when the branch could not be geocoded.
+// The coordinates of a pin code are only trusted if it lies in the state of the branch, which must resolve to a code.
+func (g *geocoder) geocode(address, district, state string) (pin string, latitude, longitude any, confidence finly.GeoConfidence) {
+	pin = finly.ExtractPIN(address)
+	stateCode, stateOK := finly.StateCode(state)
+
+	if point, ok := g.pins[pin]; ok && pin != "" {
+		switch {
+		case !stateOK || point.state == "":
+			confidence = finly.GeoConfidenceLow
+		case point.state != stateCode:
+			confidence = finly.GeoConfidenceMedium
+		default:
+			confidence = finly.GeoConfidenceHigh
+		}
+
+		return pin, point.latitude, point.longitude, confidence
+	}
+
+	if point, ok := g.districts[stateCode+"|"+strings.ToUpper(strings.TrimSpace(district))]; ok && stateOK {
+		return pin, point.latitude, point.longitude, finly.GeoConfidenceLow
+	}
+
+	return pin, nil, nil, finly.GeoConfidenceNone
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imumesh18/bifrost/finly"
+)
+
+func TestGeocode(t *testing.T) {
+	g := &geocoder{
+		pins: map[string]geoPoint{
+			"400024": {latitude: 19.07, longitude: 72.88, state: "IN-MH"},
+			"403001": {latitude: 15.49, longitude: 73.82},
+		},
+		districts: map[string]geoPoint{
+			"IN-MH|MUMBAI": {latitude: 19.08, longitude: 72.87, state: "IN-MH"},
+		},
+	}
+
+	testCases := []struct {
+		expectedLatitude   any
+		name               string
+		address            string
+		district           string
+		state              string
+		expectedPin        string
+		expectedConfidence finly.GeoConfidence
+	}{
+		{
+			name:               "pin in the state",
+			address:            "KURLA-EAST,MUMBAI-400024",
+			district:           "MUMBAI",
+			state:              "MAHARASHTRA",
+			expectedPin:        "400024",
+			expectedLatitude:   19.07,
+			expectedConfidence: finly.GeoConfidenceHigh,
+		},
+		{
+			name:               "pin in another state",
+			address:            "KURLA-EAST,MUMBAI-400024",
+			district:           "MUMBAI",
+			state:              "GUJARAT",
+			expectedPin:        "400024",
+			expectedLatitude:   19.07,
+			expectedConfidence: finly.GeoConfidenceMedium,
+		},
+		{
+			name:               "pin with an unresolved branch state",
+			address:            "KURLA-EAST,MUMBAI-400024",
+			district:           "MUMBAI",
+			state:              "BOMBAY PRESIDENCY",
+			expectedPin:        "400024",
+			expectedLatitude:   19.07,
+			expectedConfidence: finly.GeoConfidenceLow,
+		},
+		{
+			name:               "pin with an unresolved state",
+			address:            "PANAJI 403001",
+			district:           "NORTH GOA",
+			state:              "GOA",
+			expectedPin:        "403001",
+			expectedLatitude:   15.49,
+			expectedConfidence: finly.GeoConfidenceLow,
+		},
+		{
+			name:               "district",
+			address:            "KURLA-EAST,MUMBAI",
+			district:           " Mumbai ",
+			state:              "IN-MH",
+			expectedLatitude:   19.08,
+			expectedConfidence: finly.GeoConfidenceLow,
+		},
+		{
+			name:               "district with an unresolved state",
+			address:            "KURLA-EAST,MUMBAI",
+			district:           "MUMBAI",
+			state:              "",
+			expectedConfidence: finly.GeoConfidenceNone,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pin, latitude, _, confidence := g.geocode(tc.address, tc.district, tc.state)
+			assert.Equal(t, tc.expectedPin, pin)
+			assert.Equal(t, tc.expectedLatitude, latitude)
+			assert.Equal(t, tc.expectedConfidence, confidence)
+		})
+	}
+}
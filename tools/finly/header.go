@@ -0,0 +1,183 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// csvColumn maps a column of the razorpay csv to the bank column it is imported into
+type csvColumn struct {
+	// header is the upper cased header of the column in the csv
+	header string
+	// column is the bank column the values are imported into
+	column string
+	// required columns fail the import when missing, the others are imported as null
+	required bool
+}
+
+// csvColumns are the columns of the razorpay csv imported as is, the bank columns derived at import time are computed from them.
+// The upstream csv changes without notice, the columns are bound by header so that a reordered csv imports all the same.
+var csvColumns = []csvColumn{
+	{header: "BANK", column: "name", required: true},
+	{header: "IFSC", column: "ifsc", required: true},
+	{header: "BRANCH", column: "branch", required: true},
+	{header: "CENTRE", column: "center"},
+	{header: "DISTRICT", column: "district", required: true},
+	{header: "STATE", column: "state", required: true},
+	{header: "ADDRESS", column: "address", required: true},
+	{header: "CONTACT", column: "contact"},
+	{header: "IMPS", column: "imps", required: true},
+	{header: "RTGS", column: "rtgs", required: true},
+	{header: "CITY", column: "city", required: true},
+	{header: "ISO3166", column: "iso3166"},
+	{header: "NEFT", column: "neft", required: true},
+	{header: "MICR", column: "micr"},
+	{header: "UPI", column: "upi", required: true},
+	{header: "SWIFT", column: "swift"},
+}
+
+// byteOrderMark is the utf-8 byte order mark some spreadsheet tools prepend to the csv
+const byteOrderMark = "\ufeff"
+
+var (
+	errMissingColumns  = errors.New("missing required csv columns")
+	errDuplicateColumn = errors.New("duplicate csv column")
+)
+
+// csvHeader binds the columns of the razorpay csv by their header
+type csvHeader struct {
+	// positions maps the bank columns to their position in the csv, the missing optional columns are absent
+	positions map[string]int
+	// extras are the headers of the columns the importer doesn't know about by their position in the csv
+	extras map[int]string
+	// missing are the headers of the optional columns missing from the csv
+	missing []string
+}
+
+// parseHeader binds the columns of the csv by its header row.
+// It returns errMissingColumns listing every required column missing from the header.
+func parseHeader(header []string) (*csvHeader, error) {
+	h := &csvHeader{positions: make(map[string]int), extras: make(map[int]string)}
+
+	known := make(map[string]string, len(csvColumns))
+	for _, c := range csvColumns {
+		known[c.header] = c.column
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, byteOrderMark)))
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s", errDuplicateColumn, name)
+		}
+		seen[name] = true
+
+		if column, ok := known[name]; ok {
+			h.positions[column] = i
+		} else if name != "" {
+			h.extras[i] = name
+		}
+	}
+
+	var missing []string
+	for _, c := range csvColumns {
+		if _, ok := h.positions[c.column]; ok {
+			continue
+		}
+		if c.required {
+			missing = append(missing, c.header)
+		} else {
+			h.missing = append(h.missing, c.header)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", errMissingColumns, strings.Join(missing, ", "))
+	}
+
+	return h, nil
+}
+
+// extraHeaders returns the headers of the columns kept in the extras column
+func (h *csvHeader) extraHeaders() []string {
+	headers := make([]string, 0, len(h.extras))
+	for _, name := range h.extras {
+		headers = append(headers, name)
+	}
+	sort.Strings(headers)
+
+	return headers
+}
+
+// value returns the value of the bank column in the record, or an empty string if the csv lacks it
+func (h *csvHeader) value(record []string, column string) string {
+	i, ok := h.positions[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return record[i]
+}
+
+// values returns the values of csvColumns in the record, the missing optional columns are null
+func (h *csvHeader) values(record []string) []any {
+	values := make([]any, 0, len(csvColumns))
+	for _, c := range csvColumns {
+		i, ok := h.positions[c.column]
+		if !ok || i >= len(record) {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, record[i])
+	}
+
+	return values
+}
+
+// extraValues returns the values of the unknown columns of the record as a json object keyed by header,
+// or null when the csv has none
+func (h *csvHeader) extraValues(record []string) (any, error) {
+	if len(h.extras) == 0 {
+		return nil, nil
+	}
+
+	extras := make(map[string]string, len(h.extras))
+	for i, name := range h.extras {
+		if i < len(record) {
+			extras[name] = record[i]
+		}
+	}
+
+	encoded, err := json.Marshal(extras)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+// insertColumns returns the bank columns imported from the csv, in the order of values
+func insertColumns() []string {
+	columns := make([]string, 0, len(csvColumns))
+	for _, c := range csvColumns {
+		columns = append(columns, c.column)
+	}
+
+	return columns
+}
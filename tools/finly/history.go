@@ -0,0 +1,141 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// historyFields are the bank columns whose values are tracked across dataset versions
+var historyFields = []string{
+	"name",
+	"code",
+	"branch",
+	"center",
+	"district",
+	"state",
+	"address",
+	"contact",
+	"imps",
+	"rtgs",
+	"city",
+	"iso3166",
+	"neft",
+	"micr",
+	"upi",
+	"swift",
+}
+
+var (
+	errVersionImported   = errors.New("version already imported")
+	errVersionOutOfOrder = errors.New("version older than the latest imported version")
+)
+
+// insertVersion records the imported release and returns its id.
+// The history orders the versions by id, so the releases must be imported in the order they were published:
+// a release already imported or older than the latest one imported is refused.
+func insertVersion(ctx context.Context, tx *sql.Tx, name, version string) (int64, error) {
+	var latest string
+	err := tx.QueryRowContext(ctx, `SELECT version FROM version ORDER BY id DESC LIMIT 1`).Scan(&latest)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM version WHERE version = ?)`, version).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case exists:
+		return 0, fmt.Errorf("%w: %s", errVersionImported, version)
+	case latest != "" && compareReleaseTags(version, latest) < 0:
+		return 0, fmt.Errorf("%w: %s is older than %s", errVersionOutOfOrder, version, latest)
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `INSERT INTO version (name, version) VALUES (?, ?) RETURNING id`, name, version).Scan(&id)
+
+	return id, err
+}
+
+// compareReleaseTags compares two release tags by their version numbers, e.g. v2.0.9 is older than v2.0.10.
+// It returns a negative number if a is older than b, a positive one if it is newer and 0 if they are equal
+// or either isn't a release tag.
+func compareReleaseTags(a, b string) int {
+	matchA, matchB := releaseTag.FindStringSubmatch(a), releaseTag.FindStringSubmatch(b)
+	if matchA == nil || matchB == nil {
+		return 0
+	}
+
+	for i := 1; i < len(matchA); i++ {
+		// The numbers are validated by the pattern
+		numberA, _ := strconv.Atoi(matchA[i])
+		numberB, _ := strconv.Atoi(matchB[i])
+		if numberA != numberB {
+			return numberA - numberB
+		}
+	}
+
+	return 0
+}
+
+// recordHistory compares the freshly imported bank table with the current snapshots in bank_history.
+// It records the changed fields, closes the snapshots that changed or disappeared, opens snapshots
+// for new and changed branches and updates when every ifsc was last seen.
+func recordHistory(ctx context.Context, tx *sql.Tx, versionID int64) error {
+	for _, field := range historyFields {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO bank_change (ifsc, version_id, field, old_value, new_value)
+		SELECT b.ifsc, ?, '%[1]s', h.%[1]s, b.%[1]s FROM bank b
+		JOIN bank_history h ON h.ifsc = b.ifsc AND h.valid_to IS NULL
+		WHERE b.%[1]s IS NOT h.%[1]s`, field), versionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	unchanged := make([]string, len(historyFields))
+	for i, field := range historyFields {
+		unchanged[i] = fmt.Sprintf("b.%[1]s IS bank_history.%[1]s", field)
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE bank_history SET valid_to = ?
+	WHERE valid_to IS NULL AND NOT EXISTS (
+		SELECT 1 FROM bank b WHERE b.ifsc = bank_history.ifsc AND `+strings.Join(unchanged, " AND ")+`
+	)`, versionID)
+	if err != nil {
+		return err
+	}
+
+	columns := strings.Join(historyFields, ", ")
+	_, err = tx.ExecContext(ctx, `INSERT INTO bank_history (ifsc, `+columns+`, valid_from)
+	SELECT ifsc, `+columns+`, ? FROM bank b
+	WHERE NOT EXISTS (SELECT 1 FROM bank_history h WHERE h.ifsc = b.ifsc AND h.valid_to IS NULL)`, versionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO ifsc_lifecycle (ifsc, first_seen, last_seen)
+	SELECT ifsc, ?, ? FROM bank WHERE true
+	ON CONFLICT (ifsc) DO UPDATE SET last_seen = excluded.last_seen`, versionID, versionID)
+
+	return err
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertVersion(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError error
+		name          string
+		imported      []string
+		version       string
+	}{
+		{name: "first version", version: "v2.0.19"},
+		{name: "newer version", imported: []string{"v2.0.9"}, version: "v2.0.10"},
+		{name: "same version", imported: []string{"v2.0.19", "v2.0.20"}, version: "v2.0.19", expectedError: errVersionImported},
+		{name: "older version", imported: []string{"v2.0.20"}, version: "v2.0.19", expectedError: errVersionOutOfOrder},
+		{name: "older major version", imported: []string{"v3.0.0"}, version: "v2.9.9", expectedError: errVersionOutOfOrder},
+		{name: "after a label that isn't a release tag", imported: []string{"snapshot"}, version: "v2.0.19"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := sql.Open("sqlite", ":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			// Every connection to an in-memory database opens a database of its own
+			db.SetMaxOpenConns(1)
+
+			tx, err := db.BeginTx(ctx, nil)
+			require.NoError(t, err)
+			defer tx.Rollback() //nolint:errcheck // the transaction is never committed
+			require.NoError(t, createSchema(ctx, tx))
+
+			for _, version := range tc.imported {
+				_, err = insertVersion(ctx, tx, ifscFile, version)
+				require.NoError(t, err)
+			}
+
+			id, err := insertVersion(ctx, tx, ifscFile, tc.version)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tc.imported)+1), id)
+		})
+	}
+}
+
+func TestCompareReleaseTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		expected int
+	}{
+		{name: "equal", a: "v2.0.20", b: "v2.0.20", expected: 0},
+		{name: "patch", a: "v2.0.9", b: "v2.0.10", expected: -1},
+		{name: "minor", a: "v2.1.0", b: "v2.0.30", expected: 1},
+		{name: "not a release tag", a: "latest", b: "v2.0.20", expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			compared := compareReleaseTags(tc.a, tc.b)
+			switch {
+			case tc.expected < 0:
+				assert.Negative(t, compared)
+			case tc.expected > 0:
+				assert.Positive(t, compared)
+			default:
+				assert.Zero(t, compared)
+			}
+		})
+	}
+}
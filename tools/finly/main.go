@@ -19,14 +19,35 @@ import (
 	"database/sql"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
 	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/imumesh18/bifrost/finly"
+	"github.com/imumesh18/bifrost/tools/internal/dataset"
+	"github.com/imumesh18/bifrost/tools/internal/diff"
+	"github.com/imumesh18/bifrost/tools/internal/download"
+	"github.com/imumesh18/bifrost/tools/internal/quarantine"
+	"github.com/imumesh18/bifrost/tools/internal/rebuild"
+	"github.com/imumesh18/bifrost/tools/internal/report"
+)
+
+// finlyDB is the path of the database built by the importer
+const finlyDB = "./finly/data/finly.db"
 
-	_ "github.com/libsql/libsql-client-go/libsql"
-	_ "modernc.org/sqlite"
+// releaseTag matches the tags of the razorpay releases, e.g. v2.0.20, capturing the numbers of the version
+var releaseTag = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)$`)
+
+var (
+	errVersionRequired = errors.New("a version label is required to import local files")
+	errInvalidVersion  = errors.New("the version label of local files must be the razorpay release tag they come from, e.g. v2.0.20")
+	errNoAssets        = errors.New("release has no assets")
 )
 
 // The release structure is used to represent a GitHub release.
@@ -97,33 +118,78 @@ type BankCode struct {
 //nolint:funlen,gocyclo
 func main() {
 	ctx := context.Background()
-	url := "https://api.github.com/repos/razorpay/ifsc/releases/latest"
+	startedAt := time.Now()
+
+	// The release files are downloaded from github unless local files are given, e.g. on air-gapped build hosts
+	var (
+		dir, label string
+		paths      = make(map[string]string)
+	)
+	flag.StringVar(&dir, "dir", "", "local directory holding "+ifscFile+", "+banksFile+", "+subletFile+" and "+bankNamesFile)
+	flag.Func("ifsc-csv", "local path of "+ifscFile, localPath(paths, ifscFile))
+	flag.Func("banks-json", "local path of "+banksFile, localPath(paths, banksFile))
+	flag.Func("sublet-json", "local path of "+subletFile, localPath(paths, subletFile))
+	flag.Func("banknames-json", "local path of "+bankNamesFile, localPath(paths, bankNamesFile))
+	flag.StringVar(&label, "version", "", "razorpay release tag the local files come from, e.g. v2.0.20")
+	maxRejectRate := flag.Float64("max-reject-rate", quarantine.DefaultMaxRejectRate,
+		"share of invalid rows quarantined before the import fails, e.g. 0.01 for 1%")
+	cacheDir := flag.String("cache-dir", download.DefaultCacheDir("finly"), "directory the release files are downloaded into")
+	reportPath := flag.String("report", "-", "path of the json report of the run, - for the standard output")
+	flag.Parse()
+
+	// Report the outcome of the run whichever way it ends, err holds the reason of a failure
+	var err error
+	rep := report.New("finly", startedAt)
+	defer func() {
+		if writeErr := rep.Write(*reportPath, err); writeErr != nil {
+			slog.WarnContext(ctx, "error writing report", slog.Any("err", writeErr), slog.String("file", *reportPath))
+		}
+	}()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, http.NoBody)
-	if err != nil {
-		slog.ErrorContext(ctx, "error creating request", slog.Any("err", err), slog.String("url", url))
+	offline := dir != "" || len(paths) > 0
+	if offline && label == "" {
+		err = errVersionRequired
+		slog.ErrorContext(ctx, "a version label is required to import local files")
 		return
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		slog.ErrorContext(ctx, "error making request", slog.Any("err", err), slog.String("url", url))
+	// The label is recorded in version.txt, which the next online run compares with the latest release tag
+	if offline && !releaseTag.MatchString(label) {
+		err = errInvalidVersion
+		slog.ErrorContext(ctx, "invalid version label", slog.Any("err", err), slog.String("version", label))
 		return
 	}
-	defer resp.Body.Close()
 
-	var release Release
-	err = json.NewDecoder(resp.Body).Decode(&release)
-	if err != nil {
-		slog.ErrorContext(ctx, "error decoding response", slog.Any("err", err), slog.String("url", url))
-		return
-	}
+	client := download.New(*cacheDir)
+	var src source
+	if offline {
+		src = newLocalSource(dir, paths)
+	} else {
+		var release *Release
+		release, err = fetchLatestRelease(ctx, client)
+		if err != nil {
+			slog.ErrorContext(ctx, "error fetching release", slog.Any("err", err), slog.String("url", latestReleaseURL()))
+			return
+		}
+
+		if len(release.Assets) == 0 {
+			err = errNoAssets
+			slog.ErrorContext(ctx, "no assets found", slog.Any("err", err), slog.String("version", release.TagName))
+			return
+		}
+		if release.Draft || release.PreRelease {
+			rep.Status = report.StatusUpToDate
+			slog.InfoContext(ctx, "no update required", slog.String("latest_version", release.TagName))
+			return
+		}
 
-	if len(release.Assets) == 0 {
-		panic("No assets found")
+		src, label = newReleaseSource(client, release), release.TagName
 	}
 
+	// Checksum every file read, they are reported as the sources of the dataset
+	sums := newChecksumSource(src)
+	src = sums
+
 	const perm = 0644
 	// Read the TagName from version.txt file
 	f, err := os.OpenFile("./tools/finly/version.txt", os.O_RDWR|os.O_CREATE, perm)
@@ -139,144 +205,94 @@ func main() {
 		return
 	}
 
-	if release.TagName == TagName || release.Draft || release.PreRelease {
-		slog.InfoContext(ctx, "no update required", slog.String("current_version", TagName), slog.String("latest_version", release.TagName))
-		return
-	}
-
-	var assetURL, assetName, bankURL string
-	for _, asset := range release.Assets {
-		if asset.Name == "IFSC.csv" {
-			assetURL = asset.URL
-			assetName = asset.Name
-			break
-		}
-
-		if asset.Name == "banks.json" {
-			bankURL = asset.URL
-		}
-	}
-
-	if assetURL == "" {
-		slog.ErrorContext(ctx, "no asset found", slog.String("asset_name", "IFSC.csv"))
-		return
-	}
+	rep.Version, rep.PreviousVersion = label, TagName
 
-	req, err = http.NewRequestWithContext(context.TODO(), "GET", assetURL, http.NoBody)
-	if err != nil {
-		slog.ErrorContext(ctx, "error creating request", slog.Any("err", err), slog.String("url", assetURL))
+	// Local files are always imported, as they are given explicitly
+	if !offline && label == TagName {
+		rep.Status = report.StatusUpToDate
+		slog.InfoContext(ctx, "no update required", slog.String("current_version", TagName), slog.String("latest_version", label))
 		return
 	}
 
-	resp, err = client.Do(req)
+	body, err := src.Open(ctx, ifscFile)
 	if err != nil {
-		slog.ErrorContext(ctx, "error making request", slog.Any("err", err), slog.String("url", assetURL))
+		slog.ErrorContext(ctx, "error opening file", slog.Any("err", err), slog.String("location", src.Location(ifscFile)))
 		return
 	}
-	defer resp.Body.Close()
+	defer body.Close()
 
-	// Open the SQLite database
-	db, err := sql.Open("libsql", "file:./finly/data/finly.db")
+	// Rebuild a copy of the SQLite database, the database is only replaced once the import succeeds
+	build, err := rebuild.Start(ctx, finlyDB)
 	if err != nil {
-		slog.ErrorContext(ctx, "error opening database", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error opening database", slog.Any("err", err), slog.String("file", finlyDB))
 		return
 	}
-	defer db.Close()
+	defer build.Abort()
 
 	// Parse the CSV data from the HTTP request body
-	reader := csv.NewReader(resp.Body)
+	reader := csv.NewReader(body)
 	reader.FieldsPerRecord = -1 // Allow variable number of fields per record
 	reader.TrimLeadingSpace = true
 
 	// Begin a transaction
-	tx, err := db.Begin()
+	tx, err := build.DB.Begin()
 	if err != nil {
 		slog.ErrorContext(ctx, "error beginning transaction", slog.Any("err", err))
 		return
 	}
 	defer func() {
 		if err != nil {
-			err = tx.Rollback()
-			if err != nil {
-				slog.ErrorContext(ctx, "error rolling back transaction", slog.Any("err", err))
-				return
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+				slog.ErrorContext(ctx, "error rolling back transaction", slog.Any("err", rollbackErr))
 			}
 		}
 	}()
 
-	_, err = tx.Exec("DROP TABLE IF EXISTS bank")
+	// Recreate the tables rebuilt from every release and create the version and history tables if they don't exist
+	err = createSchema(ctx, tx)
 	if err != nil {
-		slog.ErrorContext(ctx, "error dropping table", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error creating schema", slog.Any("err", err))
 		return
 	}
 
-	_, err = tx.Exec("DROP TABLE IF EXISTS version")
+	// Bind the columns of the csv by its header, the upstream csv may reorder or add columns without notice
+	headerRow, err := reader.Read()
 	if err != nil {
-		slog.ErrorContext(ctx, "error dropping table", slog.Any("err", err))
-		return
-	}
-
-	// Create the Bank table if it doesn't exist
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS bank (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT,
-		code TEXT,
-		ifsc TEXT UNIQUE,
-		branch TEXT,
-		center TEXT,
-		district TEXT,
-		state TEXT,
-		address TEXT,
-		contact TEXT,
-		imps BOOLEAN,
-		rtgs BOOLEAN,
-		city TEXT,
-		iso3166 TEXT,
-		neft BOOLEAN,
-		micr TEXT,
-		upi BOOLEAN,
-		swift TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-        updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    )`)
-	if err != nil {
-		slog.ErrorContext(ctx, "error creating table", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error reading csv", slog.Any("err", err))
 		return
 	}
 
-	// Create the version table if it doesn't exist
-	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS version (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT,
-		version TEXT UNIQUE,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`)
+	header, err := parseHeader(headerRow)
 	if err != nil {
-		slog.ErrorContext(ctx, "error creating table", slog.Any("err", err))
-		return
-	}
-
-	// Prepare the insert statement
-	stmt, err := tx.Prepare(`INSERT INTO bank (
-		name,
-		ifsc,
-		branch,
-		center,
-		district,
-		state,
-		address,
-		contact,
-		imps,
-		rtgs,
-		city,
-		iso3166,
-		neft,
-		micr,
-		upi,
-		swift,
-		code
-    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		slog.ErrorContext(ctx, "error parsing csv header", slog.Any("err", err), slog.Any("header", headerRow))
+		return
+	}
+	if len(header.missing) > 0 {
+		slog.WarnContext(ctx, "optional csv columns missing, importing them as null", slog.Any("columns", header.missing))
+	}
+	if len(header.extras) > 0 {
+		slog.WarnContext(ctx, "unknown csv columns, keeping them in extras", slog.Any("columns", header.extraHeaders()))
+	}
+
+	// Prepare the insert statement, the columns of the csv are followed by the derived columns
+	columns := append(insertColumns(),
+		"code",
+		"pin",
+		"latitude",
+		"longitude",
+		"geo_confidence",
+		"address_line",
+		"address_locality",
+		"address_city",
+		"address_district",
+		"address_state",
+		"phones",
+		"sponsor_code",
+		"extras",
+	)
+	stmt, err := tx.Prepare(`INSERT INTO bank (` + strings.Join(columns, ", ") + `)
+	VALUES (?` + strings.Repeat(", ?", len(columns)-1) + `)`) //nolint:gosec // the column names are constants
 	if err != nil {
 		slog.ErrorContext(ctx, "error preparing statement", slog.Any("err", err))
 		return
@@ -285,82 +301,211 @@ func main() {
 
 	// Insert the parsed data into the Bank table
 	var values []interface{}
-	_, err = reader.Read()
+	var banks map[string]BankCode
+	err = readJSON(ctx, src, banksFile, &banks)
 	if err != nil {
-		slog.ErrorContext(ctx, "error reading csv", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error reading banks", slog.Any("err", err), slog.String("location", src.Location(banksFile)))
 		return
 	}
 
-	req, err = http.NewRequestWithContext(context.TODO(), "GET", bankURL, http.NoBody)
+	ifscBankCode := make(map[string]string)
+	for _, bank := range banks {
+		ifscBankCode[bank.Ifsc] = bank.Code
+	}
+
+	// Sub-member banks clear through a sponsor bank and their ifscs carry the sponsor's code
+	sublets, err := loadSublets(ctx, src)
 	if err != nil {
-		slog.ErrorContext(ctx, "error creating request", slog.Any("err", err), slog.String("url", bankURL))
+		slog.ErrorContext(ctx, "error loading sublets", slog.Any("err", err))
 		return
 	}
 
-	resp, err = client.Do(req)
+	bankNames, err := loadBankNames(ctx, src)
 	if err != nil {
-		slog.ErrorContext(ctx, "error making request", slog.Any("err", err), slog.String("url", bankURL))
+		slog.ErrorContext(ctx, "error loading bank names", slog.Any("err", err))
 		return
 	}
 
-	defer resp.Body.Close()
+	// Geocode the branches against the postal codes of atlas, the import carries on without coordinates if atlas is unavailable
+	geo, geoErr := loadGeocoder(ctx, atlasDB)
+	if geoErr != nil {
+		slog.WarnContext(ctx, "error loading atlas, branches will not be geocoded", slog.Any("err", geoErr))
+		geo = &geocoder{}
+	}
 
-	var banks map[string]BankCode
-	err = json.NewDecoder(resp.Body).Decode(&banks)
+	// Invalid rows are quarantined instead of failing the import, unless there are too many of them
+	q, err := quarantine.New(ctx, tx)
 	if err != nil {
-		slog.ErrorContext(ctx, "error decoding response", slog.Any("err", err), slog.Any("response_body", resp.Body))
+		slog.ErrorContext(ctx, "error creating quarantine", slog.Any("err", err))
 		return
 	}
+	defer q.Close()
 
-	ifscBankCode := make(map[string]string)
-	for _, bank := range banks {
-		ifscBankCode[bank.Ifsc] = bank.Code
-	}
-
-	var record []string
+	var (
+		record []string
+		seen   = make(map[string]bool)
+	)
 	for {
 		record, err = reader.Read()
 		if err == io.EOF {
 			break
-		} else if err != nil {
+		}
+
+		var (
+			line     int
+			reason   error
+			parseErr *csv.ParseError
+		)
+		switch {
+		case errors.As(err, &parseErr):
+			line, reason = parseErr.StartLine, quarantine.ErrMalformedRow
+		case err != nil:
 			slog.ErrorContext(ctx, "error reading csv", slog.Any("err", err))
 			return
+		default:
+			line, _ = reader.FieldPos(0)
+			reason = errDuplicateIFSC
+			if !seen[header.value(record, "ifsc")] {
+				reason = validateBranch(header, record)
+			}
+		}
+
+		if reason != nil {
+			err = q.Reject(ctx, src.Location(ifscFile), line, record, reason)
+			if err != nil {
+				slog.ErrorContext(ctx, "error quarantining row", slog.Any("err", err))
+				return
+			}
+			continue
 		}
 
-		values = make([]interface{}, len(record))
+		values = header.values(record)
 
-		for i, v := range record {
-			values[i] = v
+		// The code is the one of the actual institution, the sponsor code is only set for sublet branches
+		ifsc := header.value(record, "ifsc")
+		code, sponsorCode := ifsc[:4], any(nil)
+		if subletCode, ok := sublets[ifsc]; ok && subletCode != code {
+			code, sponsorCode = subletCode, code
+		} else if bankCode, found := ifscBankCode[ifsc]; found {
+			code = bankCode
 		}
+		values = append(values, code)
 
-		bankCode, ok := ifscBankCode[record[1]]
-		if !ok {
-			values = append(values, record[1][:4])
-		} else {
-			values = append(values, bankCode)
+		district, state := header.value(record, "district"), header.value(record, "state")
+		pin, latitude, longitude, confidence := geo.geocode(header.value(record, "address"), district, state)
+		values = append(values, pin, latitude, longitude, string(confidence))
+
+		address := finly.ParseAddress(header.value(record, "address"), header.value(record, "city"), district, state)
+		values = append(values, address.Line, address.Locality, address.City, address.District, address.State)
+
+		// The phone numbers are stored as a json array, or null when the contact has none
+		var phones any
+		if parsed := finly.ParseContact(header.value(record, "contact")); len(parsed) > 0 {
+			var encoded []byte
+			encoded, err = json.Marshal(parsed)
+			if err != nil {
+				slog.ErrorContext(ctx, "error encoding phone numbers", slog.Any("err", err))
+				return
+			}
+			phones = string(encoded)
+		}
+		values = append(values, phones, sponsorCode)
+
+		var extras any
+		extras, err = header.extraValues(record)
+		if err != nil {
+			slog.ErrorContext(ctx, "error encoding extra columns", slog.Any("err", err))
+			return
 		}
+		values = append(values, extras)
+
 		_, err = stmt.Exec(values...)
 		if err != nil {
 			slog.ErrorContext(ctx, "error executing statement", slog.Any("err", err))
 			return
 		}
+		seen[ifsc] = true
+		q.Accept()
 	}
 
-	stmt, err = tx.Prepare(`INSERT INTO version (
-		name,
-		version
-	) VALUES (?, ?)`)
+	q.LogSummary(ctx)
+	rep.Rejected = q.Reasons
+	err = q.Check(*maxRejectRate)
 	if err != nil {
-		slog.ErrorContext(ctx, "error preparing statement", slog.Any("err", err))
+		slog.ErrorContext(ctx, "too many invalid rows", slog.Any("err", err))
+		return
+	}
+
+	// Load the bank level data of banks.json
+	err = importBankMaster(ctx, tx, banks, bankNames)
+	if err != nil {
+		slog.ErrorContext(ctx, "error importing bank master", slog.Any("err", err))
+		return
+	}
+
+	// Load the bank mergers and the redirects of the retired ifsc codes
+	err = importRedirects(ctx, tx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error importing redirects", slog.Any("err", err))
+		return
+	}
+
+	// Load the upi handle registry
+	err = importUPIHandles(ctx, tx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error importing upi handles", slog.Any("err", err))
+		return
+	}
+
+	// Index the loaded data for the lookups of the finly package
+	err = createIndexes(ctx, tx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating indexes", slog.Any("err", err))
+		return
+	}
+
+	versionID, err := insertVersion(ctx, tx, ifscFile, label)
+	if err != nil {
+		slog.ErrorContext(ctx, "error inserting version", slog.Any("err", err))
 		return
 	}
 
-	_, err = stmt.Exec(assetName, release.TagName)
+	// Record which branches were added, changed or removed since the previous release
+	err = recordHistory(ctx, tx, versionID)
 	if err != nil {
-		slog.ErrorContext(ctx, "error executing statement", slog.Any("err", err))
+		slog.ErrorContext(ctx, "error recording history", slog.Any("err", err), slog.String("version", label))
 		return
 	}
 
+	// Redirect the ifscs retired by a merger since a previous release to the branches of the successor bank
+	derived, err := deriveRedirects(ctx, tx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error deriving redirects", slog.Any("err", err))
+		return
+	}
+	slog.InfoContext(ctx, "derived redirects", slog.Int("count", derived))
+
+	// Record the provenance of the imported dataset, it is built from the release files and the files maintained by hand
+	localSrcs, err := localSources()
+	if err != nil {
+		slog.ErrorContext(ctx, "error reading local files", slog.Any("err", err))
+		return
+	}
+	rep.Sources = append(sums.sources(), localSrcs...)
+
+	info := &dataset.Info{
+		Source:     src.Location(ifscFile),
+		Version:    label,
+		Checksum:   datasetChecksum(rep.Sources),
+		ImportedAt: startedAt,
+	}
+	err = recordDataset(ctx, tx, info)
+	if err != nil {
+		slog.ErrorContext(ctx, "error recording dataset", slog.Any("err", err))
+		return
+	}
+	rep.RowCounts = info.RowCounts
+
 	// Commit the transaction
 	err = tx.Commit()
 	if err != nil {
@@ -368,13 +513,38 @@ func main() {
 		return
 	}
 
-	// Update the TAG_VERSION in version.txt file
+	// Compare the rebuilt tables with the database about to be replaced
+	rep.Changes, err = diff.CountAll(ctx, build.DB, finlyDB, diff.FinlyTables)
+	if err != nil {
+		slog.ErrorContext(ctx, "error comparing with the previous database", slog.Any("err", err), slog.String("file", finlyDB))
+		return
+	}
+
+	err = build.Optimize(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error optimizing database", slog.Any("err", err))
+		return
+	}
+
+	// Swap in the rebuilt database, keeping the previous one as a rollback copy
+	err = build.Commit(ctx, rebuild.NotEmpty("bank", "bank_master"))
+	if err != nil {
+		slog.ErrorContext(ctx, "error replacing database", slog.Any("err", err), slog.String("file", finlyDB))
+		return
+	}
+
+	// Update the TAG_VERSION in version.txt file, truncating it first as the previous tag may be longer
+	err = f.Truncate(0)
+	if err != nil {
+		slog.ErrorContext(ctx, "error truncating file", slog.Any("err", err), slog.String("file", "./tool/finly/version.txt"))
+		return
+	}
 	_, err = f.Seek(0, 0)
 	if err != nil {
 		slog.ErrorContext(ctx, "error seeking file", slog.Any("err", err), slog.String("file", "./tool/finly/version.txt"))
 		return
 	}
-	_, err = fmt.Fprintf(f, "TAG_VERSION=%s", release.TagName)
+	_, err = fmt.Fprintf(f, "TAG_VERSION=%s", label)
 	if err != nil {
 		slog.ErrorContext(ctx, "error writing to file", slog.Any("err", err), slog.String("file", "./tool/finly/version.txt"))
 		return
@@ -385,5 +555,14 @@ func main() {
 		return
 	}
 
-	slog.InfoContext(ctx, "update successful", slog.String("current_version", TagName), slog.String("latest_version", release.TagName))
+	rep.Status = report.StatusUpdated
+	slog.InfoContext(ctx, "update successful", slog.String("current_version", TagName), slog.String("latest_version", label))
+}
+
+// localPath returns a flag setting the local path of the named release file
+func localPath(paths map[string]string, name string) func(string) error {
+	return func(path string) error {
+		paths[name] = path
+		return nil
+	}
 }
@@ -0,0 +1,287 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/imumesh18/bifrost/finly"
+)
+
+const (
+	// mergersFile lists the bank amalgamations, it is maintained by hand
+	mergersFile = "./tools/finly/data/mergers.json"
+	// redirectsFile maps retired ifsc codes to the ifsc codes that replaced them, it is maintained by hand
+	// for the branches deriveRedirects can't match
+	redirectsFile = "./tools/finly/data/ifsc_redirects.csv"
+	// redirectMatchThreshold is the lowest finly.MatchName score of the branch names of a retired ifsc and
+	// a branch of the successor bank in the same district for the latter to be considered its successor
+	redirectMatchThreshold = 0.8
+)
+
+// mergerRecord is an amalgamation listed in mergersFile
+type mergerRecord struct {
+	finly.Merger
+
+	// IFSCScheme is how the successor bank renumbered the ifscs of the merged bank, if it published a scheme
+	IFSCScheme *ifscScheme `json:"ifsc_scheme,omitempty"`
+}
+
+// ifscScheme is a renumbering of ifscs that swaps a prefix, e.g. SYNB0001234 became CNRB0011234
+type ifscScheme struct {
+	// LegacyPrefix is the prefix of the retired ifscs
+	LegacyPrefix string `json:"legacy_prefix"`
+
+	// SuccessorPrefix is the prefix that replaced it in the ifscs of the successor bank
+	SuccessorPrefix string `json:"successor_prefix"`
+}
+
+// importRedirects loads the mergers and the ifsc redirects into the bank_merger and ifsc_redirect tables.
+// The redirects maintained by hand take precedence over those following the renumbering schemes of the mergers.
+func importRedirects(ctx context.Context, tx *sql.Tx) error {
+	f, err := os.Open(mergersFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var mergers []mergerRecord
+	err = json.NewDecoder(f).Decode(&mergers)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mergers {
+		_, err = tx.ExecContext(ctx, `INSERT INTO bank_merger (
+			code,
+			name,
+			successor_code,
+			successor_name,
+			effective_date
+		) VALUES (?, ?, ?, ?, ?)`, m.Code, m.Name, m.SuccessorCode, m.SuccessorName, m.EffectiveDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = importRedirectsFile(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mergers {
+		if m.IFSCScheme == nil {
+			continue
+		}
+
+		err = schemeRedirects(ctx, tx, m.IFSCScheme)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importRedirectsFile loads the redirects of redirectsFile into the ifsc_redirect table
+func importRedirectsFile(ctx context.Context, tx *sql.Tx) error {
+	r, err := os.Open(redirectsFile)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	// Skip the header
+	_, err = reader.Read()
+	if err != nil {
+		return err
+	}
+
+	var record []string
+	for {
+		record, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO ifsc_redirect (legacy_ifsc, successor_ifsc) VALUES (?, ?)`,
+			strings.ToUpper(record[0]), strings.ToUpper(record[1]))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// schemeRedirects redirects the retired ifscs to the ifscs of the successor bank renumbered by the scheme.
+// The successor ifscs are those of the latest release, so every branch still open is redirected to.
+func schemeRedirects(ctx context.Context, tx *sql.Tx, scheme *ifscScheme) error {
+	_, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO ifsc_redirect (legacy_ifsc, successor_ifsc)
+	SELECT ? || substr(ifsc, ?), ifsc FROM bank WHERE substr(ifsc, 1, ?) = ?`,
+		scheme.LegacyPrefix, len(scheme.SuccessorPrefix)+1, len(scheme.SuccessorPrefix), scheme.SuccessorPrefix)
+
+	return err
+}
+
+// retiredBranch is the last known snapshot of a branch of a merged bank missing from the latest release
+type retiredBranch struct {
+	ifsc          string
+	branch        string
+	successorCode string
+	location      string
+}
+
+// successorBranch is a branch of a successor bank in the latest release
+type successorBranch struct {
+	ifsc   string
+	branch string
+}
+
+// branchLocation keys the branches of a bank by district and state, the successor of a branch is looked for in the same ones
+func branchLocation(code, district, state string) string {
+	return code + "|" + strings.ToUpper(strings.TrimSpace(district)) + "|" + strings.ToUpper(strings.TrimSpace(state))
+}
+
+// deriveRedirects redirects the ifscs of the merged banks that are missing from the latest release to the branch
+// of the successor bank in the same district with the same name, according to their last snapshot in bank_history.
+// The redirects maintained by hand take precedence, the branches matching several successor branches equally are left out.
+// It returns the number of redirects derived.
+func deriveRedirects(ctx context.Context, tx *sql.Tx) (int, error) {
+	retired, err := retiredBranches(ctx, tx)
+	if err != nil || len(retired) == 0 {
+		return 0, err
+	}
+
+	successors, err := successorBranches(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO ifsc_redirect (legacy_ifsc, successor_ifsc) VALUES (?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var derived int
+	for _, b := range retired {
+		successor, ok := closestBranch(b.branch, successors[b.location])
+		if !ok {
+			continue
+		}
+
+		var result sql.Result
+		result, err = stmt.ExecContext(ctx, b.ifsc, successor)
+		if err != nil {
+			return 0, err
+		}
+		var inserted int64
+		inserted, err = result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		derived += int(inserted)
+	}
+
+	return derived, nil
+}
+
+// retiredBranches returns the last snapshot of the branches of the merged banks missing from the latest release
+func retiredBranches(ctx context.Context, tx *sql.Tx) ([]retiredBranch, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT h.ifsc, COALESCE(h.branch, ''), COALESCE(h.district, ''), COALESCE(h.state, ''),
+		m.successor_code
+	FROM bank_history h
+	JOIN bank_merger m ON m.code = substr(h.ifsc, 1, 4)
+	WHERE h.id = (SELECT MAX(id) FROM bank_history WHERE ifsc = h.ifsc)
+	AND NOT EXISTS (SELECT 1 FROM bank b WHERE b.ifsc = h.ifsc)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var retired []retiredBranch
+	for rows.Next() {
+		var (
+			b               retiredBranch
+			district, state string
+		)
+		err = rows.Scan(&b.ifsc, &b.branch, &district, &state, &b.successorCode)
+		if err != nil {
+			return nil, err
+		}
+		b.location = branchLocation(b.successorCode, district, state)
+		retired = append(retired, b)
+	}
+
+	return retired, rows.Err()
+}
+
+// successorBranches returns the branches of the successor banks in the latest release keyed by branchLocation
+func successorBranches(ctx context.Context, tx *sql.Tx) (map[string][]successorBranch, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT ifsc, COALESCE(branch, ''), COALESCE(district, ''), COALESCE(state, '')
+	FROM bank WHERE substr(ifsc, 1, 4) IN (SELECT successor_code FROM bank_merger)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	successors := make(map[string][]successorBranch)
+	for rows.Next() {
+		var (
+			b               successorBranch
+			district, state string
+		)
+		err = rows.Scan(&b.ifsc, &b.branch, &district, &state)
+		if err != nil {
+			return nil, err
+		}
+		location := branchLocation(b.ifsc[:4], district, state)
+		successors[location] = append(successors[location], b)
+	}
+
+	return successors, rows.Err()
+}
+
+// closestBranch returns the ifsc of the candidate whose branch name matches the branch best,
+// it reports false if no candidate matches well enough or several match equally
+func closestBranch(branch string, candidates []successorBranch) (string, bool) {
+	var (
+		best      string
+		bestScore float64
+		tied      bool
+	)
+	for _, c := range candidates {
+		score := finly.MatchName(branch, c.branch)
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = c.ifsc, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+
+	return best, best != "" && !tied && bestScore >= redirectMatchThreshold
+}
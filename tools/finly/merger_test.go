@@ -0,0 +1,173 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite" // Import the sqlite driver
+)
+
+// release lists the branches of a razorpay release as ifsc, branch, district and state
+type release [][4]string
+
+// importRelease imports the branches the way the importer does, along with the hand maintained mergers and redirects
+func importRelease(ctx context.Context, t *testing.T, db *sql.DB, version string, branches release) {
+	t.Helper()
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback() //nolint:errcheck // a no-op once the transaction is committed
+
+	require.NoError(t, createSchema(ctx, tx))
+	for _, b := range branches {
+		_, err = tx.ExecContext(ctx, `INSERT INTO bank (ifsc, code, branch, district, state) VALUES (?, ?, ?, ?, ?)`,
+			b[0], b[0][:4], b[1], b[2], b[3])
+		require.NoError(t, err)
+	}
+	require.NoError(t, importRedirects(ctx, tx))
+
+	versionID, err := insertVersion(ctx, tx, ifscFile, version)
+	require.NoError(t, err)
+	require.NoError(t, recordHistory(ctx, tx, versionID))
+
+	_, err = deriveRedirects(ctx, tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+}
+
+// chdirRepository changes to the root of the repository for the duration of the test,
+// the files maintained by hand are relative to it as the importer runs there
+func chdirRepository(t *testing.T) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir("../.."))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+}
+
+// openRepository returns an in-memory database to import releases into from the root of the repository
+func openRepository(t *testing.T) *sql.DB {
+	t.Helper()
+	chdirRepository(t)
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// Every connection to an in-memory database opens a database of its own
+	db.SetMaxOpenConns(1)
+
+	return db
+}
+
+// redirects returns the successor of every legacy ifsc of the database
+func redirects(ctx context.Context, t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+
+	rows, err := db.QueryContext(ctx, `SELECT legacy_ifsc, successor_ifsc FROM ifsc_redirect`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	successors := make(map[string]string)
+	for rows.Next() {
+		var legacy, successor string
+		require.NoError(t, rows.Scan(&legacy, &successor))
+		successors[legacy] = successor
+	}
+	require.NoError(t, rows.Err())
+
+	return successors
+}
+
+func TestImportRedirectsScheme(t *testing.T) {
+	ctx := context.Background()
+	db := openRepository(t)
+
+	importRelease(ctx, t, db, "v2.0.20", release{
+		// Canara Bank renumbered the branches of Syndicate Bank, SYNB000 became CNRB001
+		{"CNRB0013006", "MANIPAL", "UDUPI", "KARNATAKA"},
+		{"CNRB0000123", "MANGALORE", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"BARB0VJMANG", "MANGALORE", "DAKSHINA KANNADA", "KARNATAKA"},
+	})
+
+	assert.Equal(t, map[string]string{"SYNB0003006": "CNRB0013006"}, redirects(ctx, t, db))
+}
+
+func TestDeriveRedirects(t *testing.T) {
+	ctx := context.Background()
+	db := openRepository(t)
+
+	importRelease(ctx, t, db, "v2.0.19", release{
+		{"VIJB0001234", "MANGALORE", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"VIJB0001235", "Attavar", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"VIJB0001236", "HAMPANKATTA", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"VIJB0001237", "BEJAI", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"VIJB0001238", "KADRI", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"HDFC0001234", "MANGALORE", "DAKSHINA KANNADA", "KARNATAKA"},
+	})
+	importRelease(ctx, t, db, "v2.0.20", release{
+		// The successor of the mangalore branch, along with another branch of the same city
+		{"BARB0VJMANG", "Mangalore", "Dakshina Kannada", "Karnataka"},
+		{"BARB0MANGCI", "MANGALORE CITY", "DAKSHINA KANNADA", "KARNATAKA"},
+		// A branch of the same name in another district
+		{"BARB0ATTAVA", "ATTAVAR", "UDUPI", "KARNATAKA"},
+		// Two branches matching equally
+		{"BARB0HAMPA1", "HAMPANKATTA", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"BARB0HAMPA2", "HAMPANKATTA", "DAKSHINA KANNADA", "KARNATAKA"},
+		// A branch still listed under its legacy ifsc
+		{"VIJB0001237", "BEJAI", "DAKSHINA KANNADA", "KARNATAKA"},
+		{"BARB0VJBEJA", "BEJAI", "DAKSHINA KANNADA", "KARNATAKA"},
+		// The branches of the banks that were not merged are not redirected
+		{"HDFC0004321", "MANGALORE", "DAKSHINA KANNADA", "KARNATAKA"},
+	})
+
+	assert.Equal(t, map[string]string{"VIJB0001234": "BARB0VJMANG"}, redirects(ctx, t, db))
+}
+
+func TestClosestBranch(t *testing.T) {
+	candidates := []successorBranch{
+		{ifsc: "SBIN0000001", branch: "KOLKATA MAIN"},
+		{ifsc: "SBIN0000002", branch: "PARK STREET"},
+		{ifsc: "SBIN0000003", branch: "PARK STREET"},
+	}
+
+	testCases := []struct {
+		name     string
+		branch   string
+		expected string
+	}{
+		{name: "same name", branch: "Kolkata Main Br", expected: "SBIN0000001"},
+		{name: "ambiguous", branch: "PARK STREET"},
+		{name: "no match", branch: "HOWRAH"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ifsc, ok := closestBranch(tc.branch, candidates)
+			assert.Equal(t, tc.expected != "", ok)
+			if ok {
+				assert.Equal(t, tc.expected, ifsc)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/imumesh18/bifrost/finly"
+)
+
+// rebuiltTables are the tables rebuilt from every release, unlike the version and history tables kept across releases
+var rebuiltTables = []string{
+	"bank",
+	"bank_master",
+	"bank_merger",
+	"ifsc_redirect",
+	"upi_handle",
+}
+
+// createSchema drops the tables rebuilt from every release and creates the tables of the finly package that don't exist
+func createSchema(ctx context.Context, tx *sql.Tx) error {
+	for _, table := range rebuiltTables {
+		if _, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			return err
+		}
+	}
+
+	for _, query := range finly.Schema {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createIndexes creates the indexes backing the lookups of the finly package, once the data is loaded
+func createIndexes(ctx context.Context, tx *sql.Tx) error {
+	for _, query := range finly.Indexes {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
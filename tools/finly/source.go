@@ -0,0 +1,249 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/imumesh18/bifrost/tools/internal/dataset"
+	"github.com/imumesh18/bifrost/tools/internal/download"
+	"github.com/imumesh18/bifrost/tools/internal/report"
+)
+
+// The files of a razorpay release the dataset is built from
+const (
+	ifscFile      = "IFSC.csv"
+	banksFile     = "banks.json"
+	subletFile    = "sublet.json"
+	bankNamesFile = "banknames.json"
+)
+
+// The base urls the release is downloaded from, the tests point them at a local server
+var (
+	// githubAPIURL is the base url of the github api
+	githubAPIURL = "https://api.github.com"
+	// githubRawURL is the base url of the files of the github repositories by revision
+	githubRawURL = "https://raw.githubusercontent.com"
+)
+
+// latestReleaseURL returns the github api endpoint of the latest razorpay/ifsc release
+func latestReleaseURL() string {
+	return githubAPIURL + "/repos/razorpay/ifsc/releases/latest"
+}
+
+// sourceFileURL returns the url of a file of the razorpay/ifsc sources by release tag, for the files not published as assets
+func sourceFileURL(tag, name string) string {
+	return fmt.Sprintf("%s/razorpay/ifsc/%s/src/%s", githubRawURL, tag, name)
+}
+
+// source provides the files of a razorpay release
+type source interface {
+	// Open returns the content of the named file, the error wraps fs.ErrNotExist if the source has no such file
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// Location returns where the named file is read from, it is recorded as the provenance of the dataset
+	Location(name string) string
+}
+
+// releaseSource downloads the files of a razorpay release from github
+type releaseSource struct {
+	client *download.Client
+	tag    string
+	// assets maps the names of the release assets to their download url
+	assets map[string]string
+}
+
+// newReleaseSource returns a source downloading the files of the release
+func newReleaseSource(client *download.Client, release *Release) *releaseSource {
+	assets := make(map[string]string, len(release.Assets))
+	for _, asset := range release.Assets {
+		assets[asset.Name] = asset.URL
+	}
+
+	return &releaseSource{client: client, tag: release.TagName, assets: assets}
+}
+
+// Location returns the download url of the release asset, or the url of the source file at the release tag
+func (s *releaseSource) Location(name string) string {
+	if url, ok := s.assets[name]; ok {
+		return url
+	}
+
+	return sourceFileURL(s.tag, name)
+}
+
+// Open downloads the named file of the release, unless the cached file is current
+func (s *releaseSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	url := s.Location(name)
+	file, err := s.client.Fetch(ctx, url)
+
+	var statusErr *download.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return nil, &fs.PathError{Op: "open", Path: url, Err: fs.ErrNotExist}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return os.Open(file.Path)
+}
+
+// localSource reads the files of a razorpay release from the local file system
+type localSource struct {
+	// paths maps the names of the files to their local path
+	paths map[string]string
+}
+
+// newLocalSource returns a source reading the files from dir, unless their path is given in paths.
+// dir may be empty if the path of every file is given.
+func newLocalSource(dir string, paths map[string]string) *localSource {
+	s := &localSource{paths: make(map[string]string)}
+	for _, name := range []string{ifscFile, banksFile, subletFile, bankNamesFile} {
+		if path := paths[name]; path != "" {
+			s.paths[name] = path
+		} else if dir != "" {
+			s.paths[name] = filepath.Join(dir, name)
+		}
+	}
+
+	return s
+}
+
+// Location returns the local path of the named file
+func (s *localSource) Location(name string) string {
+	path, ok := s.paths[name]
+	if !ok {
+		return ""
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return "file://" + abs
+}
+
+// Open opens the local file
+func (s *localSource) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	path, ok := s.paths[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return os.Open(path)
+}
+
+// checksumSource checksums the files read from a source, they are reported as the sources of the dataset
+type checksumSource struct {
+	source
+	// names are the names of the files opened, in order
+	names []string
+	// sums maps the names of the files read to the end to their checksum
+	sums map[string]string
+}
+
+// newChecksumSource returns a source checksumming the files read from src
+func newChecksumSource(src source) *checksumSource {
+	return &checksumSource{source: src, sums: make(map[string]string)}
+}
+
+// Open opens the named file of the source, its checksum is recorded once it is read to the end
+func (s *checksumSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	r, err := s.source.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	s.names = append(s.names, name)
+
+	return &checksumReader{ReadCloser: r, hash: sha256.New(), done: func(sum string) { s.sums[name] = sum }}, nil
+}
+
+// sources returns the files read from the source along with their checksum.
+// The files not read to the end are reported without one.
+func (s *checksumSource) sources() []report.Source {
+	sources := make([]report.Source, 0, len(s.names))
+	for _, name := range s.names {
+		sources = append(sources, report.Source{URL: s.Location(name), Checksum: s.sums[name]})
+	}
+
+	return sources
+}
+
+// checksumReader hashes the content read from a file and reports the checksum once the file is read to the end
+type checksumReader struct {
+	io.ReadCloser
+	hash hash.Hash
+	done func(sum string)
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		r.done(dataset.Checksum(r.hash))
+	}
+
+	return n, err
+}
+
+// readJSON decodes the named json file of the source into v
+func readJSON(ctx context.Context, src source, name string, v any) error {
+	r, err := src.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	err = json.NewDecoder(r).Decode(v)
+	if err != nil {
+		return err
+	}
+
+	// Read the trailing whitespace after the value, so that the whole file is checksummed
+	_, err = io.Copy(io.Discard, r)
+
+	return err
+}
+
+// fetchLatestRelease returns the latest razorpay/ifsc release.
+// The release is revalidated with its etag, github doesn't count the unchanged ones against the rate limit.
+func fetchLatestRelease(ctx context.Context, client *download.Client) (*Release, error) {
+	file, err := client.Fetch(ctx, latestReleaseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var release Release
+	err = json.Unmarshal(data, &release)
+	if err != nil {
+		return nil, err
+	}
+
+	return &release, nil
+}
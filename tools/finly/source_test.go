@@ -0,0 +1,167 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imumesh18/bifrost/tools/internal/download"
+)
+
+// newGitHub starts a stand-in for the github api and the raw files serving the given files by path,
+// the base urls point at it for the duration of the test. It returns the url of the server.
+func newGitHub(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, content) //nolint:errcheck // the test fails on the client side if the write does
+	}))
+	t.Cleanup(server.Close)
+
+	apiURL, rawURL := githubAPIURL, githubRawURL
+	githubAPIURL, githubRawURL = server.URL+"/api", server.URL+"/raw"
+	t.Cleanup(func() { githubAPIURL, githubRawURL = apiURL, rawURL })
+
+	return server.URL
+}
+
+func TestFetchLatestRelease(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedOutput *Release
+		name           string
+		release        string
+		expectedError  string
+	}{
+		{
+			name:    "release",
+			release: `{"name": "2.0.20", "tag_name": "v2.0.20", "assets": [{"name": "IFSC.csv", "browser_download_url": "https://example.com/IFSC.csv"}]}`,
+			expectedOutput: &Release{
+				Name:    "2.0.20",
+				TagName: "v2.0.20",
+				Assets:  []Asset{{Name: "IFSC.csv", URL: "https://example.com/IFSC.csv"}},
+			},
+		},
+		{
+			name:          "not found",
+			expectedError: "unexpected status 404 Not Found",
+		},
+		{
+			name:          "invalid json",
+			release:       `{"tag_name": `,
+			expectedError: "unexpected end of JSON input",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := make(map[string]string)
+			if tc.release != "" {
+				files["/api/repos/razorpay/ifsc/releases/latest"] = tc.release
+			}
+			newGitHub(t, files)
+
+			release, err := fetchLatestRelease(ctx, download.New(t.TempDir()))
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				assert.Nil(t, release)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, release)
+		})
+	}
+}
+
+func TestReleaseSourceOpen(t *testing.T) {
+	ctx := context.Background()
+	serverURL := newGitHub(t, map[string]string{
+		"/assets/IFSC.csv":                          "BANK,IFSC\n",
+		"/raw/razorpay/ifsc/v2.0.20/src/banks.json": `{"SBIN": {}}`,
+	})
+
+	src := newReleaseSource(download.New(t.TempDir()), &Release{
+		TagName: "v2.0.20",
+		Assets: []Asset{
+			{Name: ifscFile, URL: serverURL + "/assets/IFSC.csv"},
+			{Name: bankNamesFile, URL: serverURL + "/assets/banknames.json"},
+		},
+	})
+
+	testCases := []struct {
+		expectedError    error
+		name             string
+		file             string
+		expectedLocation string
+		expectedContent  string
+	}{
+		{
+			name:             "release asset",
+			file:             ifscFile,
+			expectedLocation: serverURL + "/assets/IFSC.csv",
+			expectedContent:  "BANK,IFSC\n",
+		},
+		{
+			name:             "source file",
+			file:             banksFile,
+			expectedLocation: githubRawURL + "/razorpay/ifsc/v2.0.20/src/banks.json",
+			expectedContent:  `{"SBIN": {}}`,
+		},
+		{
+			name:             "missing release asset",
+			file:             bankNamesFile,
+			expectedLocation: serverURL + "/assets/banknames.json",
+			expectedError:    fs.ErrNotExist,
+		},
+		{
+			name:             "missing source file",
+			file:             subletFile,
+			expectedLocation: githubRawURL + "/razorpay/ifsc/v2.0.20/src/sublet.json",
+			expectedError:    fs.ErrNotExist,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedLocation, src.Location(tc.file))
+
+			r, err := src.Open(ctx, tc.file)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			defer r.Close()
+
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContent, string(content))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// localSubletFile holds the sublet ifscs missing upstream, its entries override the upstream ones
+const localSubletFile = "./tools/finly/data/sublet.json"
+
+// loadSublets returns the code of the sub-member bank of every sublet ifsc, i.e. an ifsc
+// issued under the code of the sponsor bank the sub-member bank clears through
+func loadSublets(ctx context.Context, src source) (map[string]string, error) {
+	sublets := make(map[string]string)
+	err := readOptionalJSON(ctx, src, subletFile, &sublets)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(localSubletFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return sublets, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	local := make(map[string]string)
+	err = json.Unmarshal(data, &local)
+	if err != nil {
+		return nil, err
+	}
+	for ifsc, code := range local {
+		sublets[ifsc] = code
+	}
+
+	return sublets, nil
+}
+
+// loadBankNames returns the name of every bank code, including the sub-member banks
+func loadBankNames(ctx context.Context, src source) (map[string]string, error) {
+	names := make(map[string]string)
+	err := readOptionalJSON(ctx, src, bankNamesFile, &names)
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// readOptionalJSON decodes the named json file of the source into v, leaving v untouched if the source lacks the file
+func readOptionalJSON(ctx context.Context, src source, name string, v any) error {
+	err := readJSON(ctx, src, name, v)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.WarnContext(ctx, "file not found, importing without it", slog.String("file", name))
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// upiHandlesFile maps the upi vpa handles to their psp app and sponsor bank, it is maintained by hand
+const upiHandlesFile = "./tools/finly/data/upi_handles.json"
+
+// UPIHandle represents a upi vpa handle, i.e. the part of a vpa after the @.
+type UPIHandle struct {
+	// Handle without the leading @, e.g. okhdfcbank
+	Handle string `json:"handle"`
+
+	// PSP is the payment service provider app the handle is issued by
+	PSP string `json:"psp"`
+
+	// BankCode is the code of the sponsor bank of the handle
+	BankCode string `json:"bank_code"`
+}
+
+// importUPIHandles loads the upi handle registry into the upi_handle table
+func importUPIHandles(ctx context.Context, tx *sql.Tx) error {
+	f, err := os.Open(upiHandlesFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var handles []UPIHandle
+	err = json.NewDecoder(f).Decode(&handles)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range handles {
+		_, err = tx.ExecContext(ctx, `INSERT INTO upi_handle (handle, psp, bank_code) VALUES (?, ?, ?)`,
+			strings.ToLower(strings.TrimPrefix(h.Handle, "@")), h.PSP, strings.ToUpper(h.BankCode))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ifscPattern matches an ifsc, i.e. the 4 letter bank code, a 0 reserved for future use and the 6 character branch code
+var ifscPattern = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+
+// requiredValues are the bank columns every branch must have a value for
+var requiredValues = []string{"name", "ifsc", "branch"}
+
+// boolColumns are the bank columns holding whether the branch supports a payment rail
+var boolColumns = []string{"imps", "rtgs", "neft", "upi"}
+
+var (
+	errMissingValue  = errors.New("missing value")
+	errInvalidIFSC   = errors.New("invalid ifsc")
+	errInvalidBool   = errors.New("invalid boolean")
+	errDuplicateIFSC = errors.New("duplicate ifsc")
+)
+
+// validateBranch checks a row of the razorpay csv, rewriting its boolean columns to true or false as they are parsed.
+// The errors name the offending column but not its value, they are counted by message in the import summary.
+func validateBranch(header *csvHeader, record []string) error {
+	for _, column := range requiredValues {
+		if strings.TrimSpace(header.value(record, column)) == "" {
+			return fmt.Errorf("%w: %s", errMissingValue, column)
+		}
+	}
+
+	if !ifscPattern.MatchString(header.value(record, "ifsc")) {
+		return errInvalidIFSC
+	}
+
+	for _, column := range boolColumns {
+		i, ok := header.positions[column]
+		if !ok || i >= len(record) {
+			return fmt.Errorf("%w: %s", errMissingValue, column)
+		}
+
+		b, err := strconv.ParseBool(strings.TrimSpace(record[i]))
+		if err != nil {
+			return fmt.Errorf("%w: %s", errInvalidBool, column)
+		}
+		record[i] = strconv.FormatBool(b)
+	}
+
+	return nil
+}
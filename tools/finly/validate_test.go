@@ -0,0 +1,93 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBranch(t *testing.T) {
+	header, err := parseHeader(strings.Split("BANK,IFSC,BRANCH,CENTRE,DISTRICT,STATE,ADDRESS,CONTACT,IMPS,RTGS,CITY,NEFT,UPI", ","))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		expectedError  error
+		name           string
+		record         string
+		expectedRecord string
+	}{
+		{
+			name:           "valid",
+			record:         "State Bank of India,SBIN0000001,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,True,TRUE,KOLKATA, 1 ,false",
+			expectedRecord: "State Bank of India,SBIN0000001,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,true,true,KOLKATA,true,false",
+		},
+		{
+			name:          "missing name",
+			record:        " ,SBIN0000001,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,true,true,KOLKATA,true,false",
+			expectedError: fmt.Errorf("%w: name", errMissingValue),
+		},
+		{
+			name:          "missing branch",
+			record:        "State Bank of India,SBIN0000001,,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,true,true,KOLKATA,true,false",
+			expectedError: fmt.Errorf("%w: branch", errMissingValue),
+		},
+		{
+			// The bank code is sliced from the first 4 characters of the ifsc once validated
+			name:          "ifsc shorter than a bank code",
+			record:        "State Bank of India,SBI,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,true,true,KOLKATA,true,false",
+			expectedError: errInvalidIFSC,
+		},
+		{
+			name:          "lowercase ifsc",
+			record:        "State Bank of India,sbin0000001,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,true,true,KOLKATA,true,false",
+			expectedError: errInvalidIFSC,
+		},
+		{
+			name:          "ifsc without the reserved 0",
+			record:        "State Bank of India,SBIN1000001,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,true,true,KOLKATA,true,false",
+			expectedError: errInvalidIFSC,
+		},
+		{
+			name:          "invalid boolean",
+			record:        "State Bank of India,SBIN0000001,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,yes,true,KOLKATA,true,false",
+			expectedError: fmt.Errorf("%w: imps", errInvalidBool),
+		},
+		{
+			name:          "truncated row",
+			record:        "State Bank of India,SBIN0000001,KOLKATA MAIN,KOLKATA,KOLKATA,WEST BENGAL,SAMRIDDHI BHAWAN,,true,true,KOLKATA,true",
+			expectedError: fmt.Errorf("%w: upi", errMissingValue),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := strings.Split(tc.record, ",")
+
+			err := validateBranch(header, record)
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, strings.Split(tc.expectedRecord, ","), record)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command ifscverify checks the ifscs and bank names of a csv or ndjson file of beneficiary records
+// against finly and writes the records back enriched with the outcome.
+// It exits with status 1 if the records could not be verified and 2 if some of them are not valid.
+//
+// Usage:
+//
+//	go run ./tools/ifscverify -in vendors.csv -out report.csv
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/imumesh18/bifrost/finly"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+const (
+	// exitError is the exit status when the records could not be verified
+	exitError = 1
+	// exitNotValid is the exit status when some of the records are not valid
+	exitNotValid = 2
+)
+
+// resultColumns are the columns appended to every csv record
+var resultColumns = []string{"status", "matched_bank", "matched_branch", "matched_city", "matched_state", "successor_ifsc"}
+
+// config holds the command line flags
+type config struct {
+	in        string
+	out       string
+	format    string
+	ifscField string
+	nameField string
+}
+
+func main() {
+	ctx := context.Background()
+
+	var cfg config
+	flag.StringVar(&cfg.in, "in", "-", "csv or ndjson file of beneficiary records, - for stdin")
+	flag.StringVar(&cfg.out, "out", "-", "file the enriched report is written to, - for stdout")
+	flag.StringVar(&cfg.format, "format", "", "format of the records, csv or ndjson, guessed from the input file extension if empty")
+	flag.StringVar(&cfg.ifscField, "ifsc-field", "ifsc", "column or field holding the ifsc")
+	flag.StringVar(&cfg.nameField, "name-field", "bank_name", "column or field holding the optional bank name")
+	flag.Parse()
+
+	counts, err := run(ctx, &cfg)
+	if err != nil {
+		slog.ErrorContext(ctx, "error verifying records", slog.Any("err", err))
+	}
+	os.Exit(exitCode(counts, err))
+}
+
+// exitCode returns the exit status of a run given the number of records of every status
+func exitCode(counts map[Status]int, err error) int {
+	if err != nil {
+		return exitError
+	}
+
+	for status, count := range counts {
+		if status != StatusValid && count > 0 {
+			return exitNotValid
+		}
+	}
+
+	return 0
+}
+
+// run verifies the records of the input, writes the report and returns the number of records of every status
+func run(ctx context.Context, cfg *config) (map[Status]int, error) {
+	format := cfg.format
+	if format == "" {
+		format = formatCSV
+		if ext := strings.ToLower(filepath.Ext(cfg.in)); ext == ".ndjson" || ext == ".jsonl" {
+			format = formatNDJSON
+		}
+	}
+	if format != formatCSV && format != formatNDJSON {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	in := os.Stdin
+	if cfg.in != "-" {
+		f, err := os.Open(cfg.in)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	out := os.Stdout
+	if cfg.out != "-" {
+		f, err := os.Create(cfg.out)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	store, err := finly.New()
+	if err != nil {
+		return nil, err
+	}
+
+	v := newVerifier(store)
+	counts := make(map[Status]int)
+	if format == formatCSV {
+		err = verifyCSV(ctx, v, cfg, in, out, counts)
+	} else {
+		err = verifyNDJSON(ctx, v, cfg, in, out, counts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "verified records",
+		slog.Int(string(StatusValid), counts[StatusValid]),
+		slog.Int(string(StatusUnknown), counts[StatusUnknown]),
+		slog.Int(string(StatusMerged), counts[StatusMerged]),
+		slog.Int(string(StatusNameMismatch), counts[StatusNameMismatch]),
+	)
+
+	return counts, nil
+}
+
+// verifyCSV verifies the records of a csv with a header row, appending the result columns to every record
+func verifyCSV(ctx context.Context, v *verifier, cfg *config, in io.Reader, out io.Writer, counts map[Status]int) error {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	writer := csv.NewWriter(out)
+
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+
+	ifscIndex, nameIndex := -1, -1
+	for i, column := range header {
+		switch strings.TrimSpace(strings.ToLower(column)) {
+		case strings.ToLower(cfg.ifscField):
+			ifscIndex = i
+		case strings.ToLower(cfg.nameField):
+			nameIndex = i
+		}
+	}
+	if ifscIndex < 0 {
+		return fmt.Errorf("column %q not found", cfg.ifscField)
+	}
+
+	err = writer.Write(append(header, resultColumns...))
+	if err != nil {
+		return err
+	}
+
+	for {
+		var record []string
+		record, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		var ifsc, name string
+		if ifscIndex < len(record) {
+			ifsc = record[ifscIndex]
+		}
+		if nameIndex >= 0 && nameIndex < len(record) {
+			name = record[nameIndex]
+		}
+
+		var result Result
+		result, err = v.verify(ctx, ifsc, name)
+		if err != nil {
+			return err
+		}
+		counts[result.Status]++
+
+		err = writer.Write(append(record,
+			string(result.Status), result.Bank, result.Branch, result.City, result.State, result.SuccessorIfsc))
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// verifyNDJSON verifies the records of a newline delimited json stream, adding the result under the verification field
+func verifyNDJSON(ctx context.Context, v *verifier, cfg *config, in io.Reader, out io.Writer, counts map[Status]int) error {
+	decoder := json.NewDecoder(in)
+	decoder.UseNumber()
+	encoder := json.NewEncoder(out)
+
+	for {
+		var record map[string]any
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		ifsc, name := stringField(record, cfg.ifscField), stringField(record, cfg.nameField)
+
+		result, err := v.verify(ctx, ifsc, name)
+		if err != nil {
+			return err
+		}
+		counts[result.Status]++
+
+		record["verification"] = result
+		err = encoder.Encode(record)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// stringField returns the string value of the field of a json record, the field name is matched case-insensitively
+// like the csv columns unless the record has a field of the exact name
+func stringField(record map[string]any, name string) string {
+	if value, ok := record[name]; ok {
+		s, _ := value.(string)
+		return s
+	}
+
+	for field, value := range record {
+		if strings.EqualFold(strings.TrimSpace(field), name) {
+			s, _ := value.(string)
+			return s
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCSV(t *testing.T) {
+	v := newVerifierFixture(t)
+
+	testCases := []struct {
+		name           string
+		input          string
+		expectedOutput string
+		expectedCounts map[Status]int
+		expectedError  string
+	}{
+		{
+			name:  "records",
+			input: "vendor, IFSC ,Bank_Name\nacme,SBIN0000300,State Bank of India\nglobex,VIJB0001234,\ninitech,SBIN0000300,HDFC Bank\nhooli\n",
+			expectedOutput: "vendor,\" IFSC \",Bank_Name,status,matched_bank,matched_branch,matched_city,matched_state,successor_ifsc\n" +
+				"acme,SBIN0000300,State Bank of India,valid,State Bank of India,MUMBAI MAIN,MUMBAI,MAHARASHTRA,\n" +
+				"globex,VIJB0001234,,merged,Bank of Baroda,MANGALORE,MANGALORE,KARNATAKA,BARB0VJMANG\n" +
+				"initech,SBIN0000300,HDFC Bank,name-mismatch,State Bank of India,MUMBAI MAIN,MUMBAI,MAHARASHTRA,\n" +
+				"hooli,unknown,,,,,\n",
+			expectedCounts: map[Status]int{StatusValid: 1, StatusMerged: 1, StatusNameMismatch: 1, StatusUnknown: 1},
+		},
+		{
+			name:           "missing ifsc column",
+			input:          "vendor,bank_name\nacme,State Bank of India\n",
+			expectedCounts: map[Status]int{},
+			expectedError:  `column "ifsc" not found`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			counts := make(map[Status]int)
+			err := verifyCSV(context.Background(), v, &config{ifscField: "ifsc", nameField: "bank_name"},
+				strings.NewReader(tc.input), &out, counts)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedOutput, out.String())
+			}
+			assert.Equal(t, tc.expectedCounts, counts)
+		})
+	}
+}
+
+func TestVerifyNDJSON(t *testing.T) {
+	v := newVerifierFixture(t)
+
+	testCases := []struct {
+		name           string
+		input          string
+		expectedOutput string
+		expectedCounts map[Status]int
+		expectedError  string
+	}{
+		{
+			name: "records",
+			input: `{"vendor":"acme","ifsc":"SBIN0000300","bank_name":"State Bank of India","amount":12.50}` + "\n" +
+				`{"vendor":"globex","IFSC":"VIJB0001234"}` + "\n" +
+				`{"vendor":"initech","Ifsc":"SBIN0000300","Bank_Name":"HDFC Bank"}` + "\n" +
+				`{"vendor":"hooli","ifsc":42}` + "\n",
+			expectedOutput: `{"amount":12.50,"bank_name":"State Bank of India","ifsc":"SBIN0000300","vendor":"acme",` +
+				`"verification":{"status":"valid","bank":"State Bank of India","branch":"MUMBAI MAIN","city":"MUMBAI",` +
+				`"state":"MAHARASHTRA"}}` + "\n" +
+				`{"IFSC":"VIJB0001234","vendor":"globex","verification":{"status":"merged","bank":"Bank of Baroda",` +
+				`"branch":"MANGALORE","city":"MANGALORE","state":"KARNATAKA","successor_ifsc":"BARB0VJMANG"}}` + "\n" +
+				`{"Bank_Name":"HDFC Bank","Ifsc":"SBIN0000300","vendor":"initech","verification":{"status":"name-mismatch",` +
+				`"bank":"State Bank of India","branch":"MUMBAI MAIN","city":"MUMBAI","state":"MAHARASHTRA"}}` + "\n" +
+				`{"ifsc":42,"vendor":"hooli","verification":{"status":"unknown"}}` + "\n",
+			expectedCounts: map[Status]int{StatusValid: 1, StatusMerged: 1, StatusNameMismatch: 1, StatusUnknown: 1},
+		},
+		{
+			name:           "invalid json",
+			input:          `{"ifsc":"SBIN0000300"`,
+			expectedCounts: map[Status]int{},
+			expectedError:  "unexpected EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			counts := make(map[Status]int)
+			err := verifyNDJSON(context.Background(), v, &config{ifscField: "ifsc", nameField: "bank_name"},
+				strings.NewReader(tc.input), &out, counts)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedOutput, out.String())
+			}
+			assert.Equal(t, tc.expectedCounts, counts)
+		})
+	}
+}
+
+func TestStringField(t *testing.T) {
+	testCases := []struct {
+		name     string
+		record   map[string]any
+		field    string
+		expected string
+	}{
+		{name: "exact name", record: map[string]any{"ifsc": "SBIN0000300", "IFSC": "HDFC0000001"}, field: "ifsc", expected: "SBIN0000300"},
+		{name: "other case", record: map[string]any{" IFSC ": "SBIN0000300"}, field: "ifsc", expected: "SBIN0000300"},
+		{name: "not a string", record: map[string]any{"ifsc": 42}, field: "ifsc"},
+		{name: "missing", record: map[string]any{"bank_name": "State Bank of India"}, field: "ifsc"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, stringField(tc.record, tc.field))
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		counts   map[Status]int
+		err      error
+		expected int
+	}{
+		{name: "every record valid", counts: map[Status]int{StatusValid: 3}, expected: 0},
+		{name: "no records", counts: map[Status]int{}, expected: 0},
+		{name: "unknown record", counts: map[Status]int{StatusValid: 3, StatusUnknown: 1}, expected: exitNotValid},
+		{name: "merged record", counts: map[Status]int{StatusMerged: 1}, expected: exitNotValid},
+		{name: "name mismatch", counts: map[Status]int{StatusValid: 1, StatusNameMismatch: 2}, expected: exitNotValid},
+		{name: "error", err: errors.New("file is not a database"), expected: exitError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, exitCode(tc.counts, tc.err))
+		})
+	}
+}
+
+func TestRunUnsupportedFormat(t *testing.T) {
+	counts, err := run(context.Background(), &config{in: "vendors.xlsx", format: "xlsx"})
+	assert.EqualError(t, err, `unsupported format "xlsx"`)
+	assert.Nil(t, counts)
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/imumesh18/bifrost/finly"
+)
+
+// Status is the outcome of the verification of a beneficiary record
+type Status string
+
+const (
+	// StatusValid means the ifsc exists and the bank name, if any, matches it
+	StatusValid Status = "valid"
+	// StatusUnknown means the ifsc is not part of the dataset
+	StatusUnknown Status = "unknown"
+	// StatusMerged means the ifsc was retired after its bank merged into another one
+	StatusMerged Status = "merged"
+	// StatusNameMismatch means the ifsc exists but belongs to another bank than the one named in the record
+	StatusNameMismatch Status = "name-mismatch"
+)
+
+// nameMatchThreshold is the lowest finly.MatchName score of a bank name still considered the same bank,
+// it tolerates typos and abbreviations such as SBI
+const nameMatchThreshold = 0.8
+
+// Result represents the verification of a beneficiary record
+type Result struct {
+	// Status specifies the outcome of the verification
+	Status Status `json:"status"`
+	// Bank specifies the name of the bank the ifsc belongs to, or of its successor when merged
+	Bank string `json:"bank,omitempty"`
+	// Branch specifies the branch the ifsc belongs to
+	Branch string `json:"branch,omitempty"`
+	// City specifies the city of the branch
+	City string `json:"city,omitempty"`
+	// State specifies the state of the branch
+	State string `json:"state,omitempty"`
+	// SuccessorIfsc specifies the ifsc replacing a retired one, if known
+	SuccessorIfsc string `json:"successor_ifsc,omitempty"`
+}
+
+// verifier checks the beneficiary records against finly, caching the lookups as vendor files repeat ifscs
+type verifier struct {
+	finly *finly.Finly
+	cache map[string]Result
+}
+
+// newVerifier returns a verifier backed by the given finly store
+func newVerifier(f *finly.Finly) *verifier {
+	return &verifier{finly: f, cache: make(map[string]Result)}
+}
+
+// verify checks an ifsc and the optional bank name of a beneficiary record
+func (v *verifier) verify(ctx context.Context, ifsc, name string) (Result, error) {
+	ifsc = strings.ToUpper(strings.TrimSpace(ifsc))
+
+	result, ok := v.cache[ifsc]
+	if !ok {
+		var err error
+		result, err = v.lookup(ctx, ifsc)
+		if err != nil {
+			return Result{}, err
+		}
+		v.cache[ifsc] = result
+	}
+
+	if result.Status == StatusValid && strings.TrimSpace(name) != "" && !namesMatch(name, result.Bank) {
+		result.Status = StatusNameMismatch
+	}
+
+	return result, nil
+}
+
+// lookup returns the verification of an ifsc regardless of the bank name
+func (v *verifier) lookup(ctx context.Context, ifsc string) (Result, error) {
+	if ifsc == "" {
+		return Result{Status: StatusUnknown}, nil
+	}
+
+	bank, err := v.finly.GetBankByIFSC(ctx, ifsc)
+	if err == nil {
+		return newResult(StatusValid, bank), nil
+	} else if !errors.Is(err, finly.ErrBankNotFound) {
+		return Result{}, err
+	}
+
+	redirect, err := v.finly.GetIFSCRedirect(ctx, ifsc)
+	if errors.Is(err, finly.ErrRedirectNotFound) {
+		return Result{Status: StatusUnknown}, nil
+	} else if err != nil {
+		return Result{}, err
+	}
+
+	if redirect.SuccessorIfsc != "" {
+		bank, err = v.finly.GetBankByIFSC(ctx, ifsc, finly.FollowRedirect())
+		if err == nil {
+			result := newResult(StatusMerged, bank)
+			result.SuccessorIfsc = bank.Ifsc
+
+			return result, nil
+		} else if !errors.Is(err, finly.ErrBankNotFound) {
+			return Result{}, err
+		}
+	}
+
+	result := Result{Status: StatusMerged, SuccessorIfsc: redirect.SuccessorIfsc}
+	if redirect.Merger != nil {
+		result.Bank = redirect.Merger.SuccessorName
+	}
+
+	return result, nil
+}
+
+// newResult returns a result describing the given branch
+func newResult(status Status, bank *finly.Bank) Result {
+	return Result{
+		Status: status,
+		Bank:   bank.Name,
+		Branch: bank.Branch,
+		City:   bank.City,
+		State:  bank.State,
+	}
+}
+
+// namesMatch reports whether the bank name of a record refers to the bank of the dataset
+func namesMatch(name, bank string) bool {
+	return finly.MatchName(name, bank) >= nameMatchThreshold
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imumesh18/bifrost/finly"
+)
+
+// newVerifierFixture returns a verifier backed by a database of a few branches, redirects and mergers.
+// finly opens the database of the project root, so the test runs in a project of its own.
+func newVerifierFixture(t *testing.T) *verifier {
+	t.Helper()
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module fixture\n"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "finly", "data"), 0o700))
+
+	db, err := sql.Open("sqlite", filepath.Join(root, "finly", "data", "finly.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE bank (name TEXT, code TEXT, ifsc TEXT PRIMARY KEY, branch TEXT, center TEXT, district TEXT,
+			state TEXT, address TEXT, contact TEXT, imps BOOLEAN, rtgs BOOLEAN, city TEXT, iso3166 TEXT,
+			neft BOOLEAN, micr TEXT, upi BOOLEAN, swift TEXT)`,
+		`CREATE TABLE ifsc_redirect (legacy_ifsc TEXT PRIMARY KEY, successor_ifsc TEXT NOT NULL)`,
+		`CREATE TABLE bank_merger (code TEXT PRIMARY KEY, name TEXT, successor_code TEXT, successor_name TEXT,
+			effective_date TEXT)`,
+		`INSERT INTO bank VALUES
+			('State Bank of India', 'SBIN', 'SBIN0000300', 'MUMBAI MAIN', '', 'MUMBAI', 'MAHARASHTRA',
+				'MUMBAI MAIN BRANCH, FORT, MUMBAI 400001', '', true, true, 'MUMBAI', 'IN-MH', true, '', true, ''),
+			('Bank of Baroda', 'BARB', 'BARB0VJMANG', 'MANGALORE', '', 'DAKSHINA KANNADA', 'KARNATAKA',
+				'BALMATTA ROAD, MANGALORE 575001', '', true, true, 'MANGALORE', 'IN-KA', true, '', true, '')`,
+		`INSERT INTO ifsc_redirect VALUES ('VIJB0001234', 'BARB0VJMANG'), ('ORBC0100001', 'PUNB0000001')`,
+		`INSERT INTO bank_merger VALUES
+			('VIJB', 'Vijaya Bank', 'BARB', 'Bank of Baroda', '2019-04-01'),
+			('DENA', 'Dena Bank', 'BARB', 'Bank of Baroda', '2019-04-01')`,
+	}
+	for _, statement := range statements {
+		_, err = db.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(root))
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+
+	store, err := finly.New()
+	require.NoError(t, err)
+
+	return newVerifier(store)
+}
+
+func TestVerify(t *testing.T) {
+	v := newVerifierFixture(t)
+
+	testCases := []struct {
+		name     string
+		ifsc     string
+		bankName string
+		expected Result
+	}{
+		{
+			name:     "valid",
+			ifsc:     "SBIN0000300",
+			bankName: "State Bank of India",
+			expected: Result{Status: StatusValid, Bank: "State Bank of India", Branch: "MUMBAI MAIN", City: "MUMBAI", State: "MAHARASHTRA"},
+		},
+		{
+			name:     "valid without bank name",
+			ifsc:     " sbin0000300 ",
+			expected: Result{Status: StatusValid, Bank: "State Bank of India", Branch: "MUMBAI MAIN", City: "MUMBAI", State: "MAHARASHTRA"},
+		},
+		{
+			name:     "name mismatch of a cached ifsc",
+			ifsc:     "SBIN0000300",
+			bankName: "HDFC Bank",
+			expected: Result{Status: StatusNameMismatch, Bank: "State Bank of India", Branch: "MUMBAI MAIN", City: "MUMBAI", State: "MAHARASHTRA"},
+		},
+		{
+			name:     "unknown",
+			ifsc:     "HDFC0000001",
+			bankName: "HDFC Bank",
+			expected: Result{Status: StatusUnknown},
+		},
+		{
+			name:     "empty ifsc",
+			expected: Result{Status: StatusUnknown},
+		},
+		{
+			name:     "merged with successor branch",
+			ifsc:     "VIJB0001234",
+			bankName: "Vijaya Bank",
+			expected: Result{
+				Status:        StatusMerged,
+				Bank:          "Bank of Baroda",
+				Branch:        "MANGALORE",
+				City:          "MANGALORE",
+				State:         "KARNATAKA",
+				SuccessorIfsc: "BARB0VJMANG",
+			},
+		},
+		{
+			name:     "merged with successor ifsc missing from the dataset",
+			ifsc:     "ORBC0100001",
+			expected: Result{Status: StatusMerged, SuccessorIfsc: "PUNB0000001"},
+		},
+		{
+			name:     "merged without successor ifsc",
+			ifsc:     "DENA0000001",
+			expected: Result{Status: StatusMerged, Bank: "Bank of Baroda"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := v.verify(context.Background(), tc.ifsc, tc.bankName)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
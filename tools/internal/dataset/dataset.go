@@ -0,0 +1,110 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dataset records the provenance of the data imported by the tools.
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"time"
+)
+
+// createTableQuery creates the table holding the provenance of every import
+const createTableQuery = `CREATE TABLE IF NOT EXISTS dataset (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	source TEXT,
+	version TEXT,
+	checksum TEXT,
+	row_counts TEXT,
+	imported_at TEXT
+)`
+
+// Info represents the provenance of an imported dataset.
+type Info struct {
+	// Source is the URL the dataset was downloaded from
+	Source string
+
+	// Version is the upstream version of the dataset
+	Version string
+
+	// Checksum is the checksum of the sources the dataset was built from in the algorithm:hex format
+	Checksum string
+
+	// RowCounts is the number of rows of every table imported from the dataset
+	RowCounts map[string]int64
+
+	// ImportedAt is the time the dataset was imported
+	ImportedAt time.Time
+}
+
+// CreateTable creates the dataset table if it doesn't exist.
+func CreateTable(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, createTableQuery)
+	return err
+}
+
+// CountRows returns the number of rows of every table.
+// The table names are trusted, they must never come from user input.
+func CountRows(ctx context.Context, tx *sql.Tx, tables ...string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(tables))
+	for _, table := range tables {
+		var count int64
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count) //nolint:gosec // table names are constants
+		if err != nil {
+			return nil, err
+		}
+		counts[table] = count
+	}
+
+	return counts, nil
+}
+
+// Record inserts the provenance of the imported dataset.
+func Record(ctx context.Context, tx *sql.Tx, info *Info) error {
+	rowCounts, err := json.Marshal(info.RowCounts)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO dataset (
+		source,
+		version,
+		checksum,
+		row_counts,
+		imported_at
+	) VALUES (?, ?, ?, ?, ?)`, info.Source, info.Version, info.Checksum, string(rowCounts), info.ImportedAt.UTC().Format(time.RFC3339))
+
+	return err
+}
+
+// LatestVersion returns the upstream version of the latest imported dataset, or an empty string if none was imported.
+func LatestVersion(ctx context.Context, tx *sql.Tx) (string, error) {
+	var version sql.NullString
+	err := tx.QueryRowContext(ctx, "SELECT version FROM dataset ORDER BY id DESC LIMIT 1").Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return version.String, err
+}
+
+// Checksum formats the sum of a sha256 hash in the algorithm:hex format.
+func Checksum(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
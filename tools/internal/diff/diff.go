@@ -0,0 +1,503 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff compares the tables of two versions of a database built by the tools.
+//
+// The previous version is attached to a connection of the current one, so that the rows of both
+// are compared by sqlite itself, matching the rows of a table by their key columns.
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// previousSchema is the name the previous database is attached under
+	previousSchema = "previous"
+	// ordinalColumn numbers the rows of a repeated key
+	ordinalColumn = "diff_ordinal"
+)
+
+// ignoredColumns are the columns left out of the comparison, as they differ between any two imports
+var ignoredColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// Table identifies the rows of a table across two databases by their key columns.
+type Table struct {
+	// Name is the name of the table
+	Name string
+	// Key are the columns identifying a row of the table
+	Key []string
+	// Repeated reports whether several rows may share a key. The rows of a key are then numbered in the order of
+	// their values and paired by number across the databases, rather than every row joined with every other one.
+	Repeated bool
+}
+
+// FinlyTables are the tables of finly rebuilt from every release, by the key of their rows
+var FinlyTables = []Table{
+	{Name: "bank", Key: []string{"ifsc"}},
+	{Name: "bank_master", Key: []string{"code"}},
+	{Name: "bank_merger", Key: []string{"code"}},
+	{Name: "ifsc_redirect", Key: []string{"legacy_ifsc"}},
+	{Name: "upi_handle", Key: []string{"handle"}},
+}
+
+// AtlasTables are the tables of atlas, by the key of their rows.
+// A postal code spans several places, the place name tells them apart, though geonames lists a few places twice.
+var AtlasTables = []Table{
+	{Name: "geo_location", Key: []string{"country_code", "postal_code", "place_name"}, Repeated: true},
+}
+
+var (
+	ErrUnknownDatabase    = errors.New("unknown database")
+	ErrMismatchedDatabase = errors.New("databases of different kinds")
+)
+
+// kinds are the tables of every database built by the tools, a database is told apart by the first of its tables
+var kinds = [][]Table{FinlyTables, AtlasTables}
+
+// Kind of a change of a row
+type Kind string
+
+const (
+	// KindAdded is a key missing from the previous database
+	KindAdded Kind = "added"
+	// KindRemoved is a key missing from the current database
+	KindRemoved Kind = "removed"
+	// KindChanged is a key found in both databases whose rows differ
+	KindChanged Kind = "changed"
+)
+
+// Change is a row of a table added, removed or changed since the previous database.
+type Change struct {
+	// Kind of the change
+	Kind Kind `json:"kind"`
+	// Key is the value of the key columns of the row by column
+	Key map[string]string `json:"key"`
+	// Fields are the columns whose value changed, only set for a changed row
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange is a column of a row whose value changed.
+type FieldChange struct {
+	// Column is the name of the column
+	Column string `json:"column"`
+	// Previous is the value of the column in the previous database
+	Previous any `json:"previous"`
+	// Current is the value of the column in the current database
+	Current any `json:"current"`
+}
+
+// Counts is the number of rows of a table added, removed and changed since the previous database.
+type Counts struct {
+	// Added is the number of keys missing from the previous database
+	Added int64 `json:"added"`
+	// Removed is the number of keys missing from the current database
+	Removed int64 `json:"removed"`
+	// Changed is the number of keys found in both databases whose rows differ
+	Changed int64 `json:"changed"`
+}
+
+// Comparison compares a database with its previous version.
+type Comparison struct {
+	conn *sql.Conn
+}
+
+// ReadOnlyURI returns the sqlite uri of the database at path opened read-only
+func ReadOnlyURI(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(abs), RawQuery: "mode=ro"}
+
+	return uri.String(), nil
+}
+
+// Open attaches the previous database at path read-only to a connection of the database.
+// A missing previous database compares as an empty one, every row then counts as added.
+func Open(ctx context.Context, db *sql.DB, path string) (*Comparison, error) {
+	_, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		path = ":memory:"
+	case err != nil:
+		return nil, err
+	default:
+		path, err = ReadOnlyURI(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Attached databases are bound to the connection, every query of the comparison runs on it
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+previousSchema, path)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Comparison{conn: conn}, nil
+}
+
+// CountAll compares the tables of the database with the previous database at path, by table name.
+func CountAll(ctx context.Context, db *sql.DB, path string, tables []Table) (map[string]Counts, error) {
+	c, err := Open(ctx, db, path)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Counts, len(tables))
+	for _, t := range tables {
+		var counts Counts
+		counts, err = c.Count(ctx, t)
+		if err != nil {
+			return nil, errors.Join(err, c.Close())
+		}
+		changes[t.Name] = counts
+	}
+
+	return changes, c.Close()
+}
+
+// Tables returns the tables of the kind of database both databases are, told apart by their tables.
+// It returns ErrUnknownDatabase if the current database is not built by the tools, and
+// ErrMismatchedDatabase if the previous database is of another kind.
+func (c *Comparison) Tables(ctx context.Context) ([]Table, error) {
+	for _, tables := range kinds {
+		current, err := c.columns(ctx, "main", tables[0].Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(current) == 0 {
+			continue
+		}
+
+		previous, err := c.columns(ctx, previousSchema, tables[0].Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(previous) == 0 {
+			return nil, fmt.Errorf("%w: the previous database has no %s table", ErrMismatchedDatabase, tables[0].Name)
+		}
+
+		return tables, nil
+	}
+
+	return nil, ErrUnknownDatabase
+}
+
+// Count returns the number of rows of the table added, removed and changed since the previous database.
+// The rows are compared on the columns both versions of the table have, but for the row id and timestamps.
+func (c *Comparison) Count(ctx context.Context, t Table) (Counts, error) {
+	var counts Counts
+
+	current, err := c.columns(ctx, "main", t.Name)
+	if err != nil {
+		return counts, err
+	}
+	previous, err := c.columns(ctx, previousSchema, t.Name)
+	if err != nil {
+		return counts, err
+	}
+
+	// A table missing from either database has every row of the other added or removed
+	switch {
+	case len(current) == 0 && len(previous) == 0:
+		return counts, nil
+	case len(previous) == 0:
+		err = c.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM main."+t.Name).Scan(&counts.Added) //nolint:gosec // table names are constants
+		return counts, err
+	case len(current) == 0:
+		query := "SELECT COUNT(*) FROM " + previousSchema + "." + t.Name
+		err = c.conn.QueryRowContext(ctx, query).Scan(&counts.Removed)
+		return counts, err
+	}
+
+	compared := comparedColumns(t, current, previous)
+
+	err = c.conn.QueryRowContext(ctx, missingQuery(t, "main", previousSchema, compared)).Scan(&counts.Added)
+	if err != nil {
+		return counts, err
+	}
+
+	err = c.conn.QueryRowContext(ctx, missingQuery(t, previousSchema, "main", compared)).Scan(&counts.Removed)
+	if err != nil {
+		return counts, err
+	}
+
+	if len(compared) == 0 {
+		return counts, nil
+	}
+
+	err = c.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+tableRows(t, "main", compared)+` n
+	JOIN `+tableRows(t, previousSchema, compared)+` p ON `+keyCondition(t, "n", "p")+`
+	WHERE `+changedCondition(compared, "n", "p")).Scan(&counts.Changed) //nolint:gosec // table and column names are constants
+	if err != nil {
+		return counts, err
+	}
+
+	return counts, nil
+}
+
+// Changes returns the rows of the table added, removed and changed since the previous database, ordered by key.
+// The changed rows list the columns whose value differ, compared as Count does.
+func (c *Comparison) Changes(ctx context.Context, t Table) ([]Change, error) {
+	current, err := c.columns(ctx, "main", t.Name)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := c.columns(ctx, previousSchema, t.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	compared := comparedColumns(t, current, previous)
+
+	var changes []Change
+	if len(current) > 0 {
+		changes, err = c.missingKeys(ctx, t, compared, "main", previousSchema, len(previous) > 0, KindAdded, changes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(previous) > 0 {
+		changes, err = c.missingKeys(ctx, t, compared, previousSchema, "main", len(current) > 0, KindRemoved, changes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(current) == 0 || len(previous) == 0 || len(compared) == 0 {
+		return changes, nil
+	}
+
+	return c.changedRows(ctx, t, compared, changes)
+}
+
+// missingKeys appends the keys of the table in the from schema missing from the other schema to changes,
+// every key is missing if the other schema lacks the table
+func (c *Comparison) missingKeys(ctx context.Context, t Table, compared []string, from, other string, otherExists bool,
+	kind Kind, changes []Change,
+) ([]Change, error) {
+	query := `SELECT ` + qualified(t.Key, "n") + ` FROM ` + tableRows(t, from, compared) + ` n`
+	if otherExists {
+		query += ` WHERE NOT EXISTS (SELECT 1 FROM ` + tableRows(t, other, compared) + ` p WHERE ` + keyCondition(t, "n", "p") + `)`
+	}
+	query += ` ORDER BY ` + qualified(t.Key, "n")
+
+	rows, err := c.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	key := make([]sql.NullString, len(t.Key))
+	dest := make([]any, len(key))
+	for i := range key {
+		dest[i] = &key[i]
+	}
+
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, Change{Kind: kind, Key: keyValues(t, key)})
+	}
+
+	return changes, rows.Err()
+}
+
+// changedRows appends the rows of the table found in both databases whose compared columns differ to changes
+func (c *Comparison) changedRows(ctx context.Context, t Table, compared []string, changes []Change) ([]Change, error) {
+	// Every compared column is selected from both databases along with whether its value changed
+	selected := []string{qualified(t.Key, "n")}
+	for _, column := range compared {
+		selected = append(selected, "n."+column, "p."+column, "n."+column+" IS NOT p."+column)
+	}
+
+	rows, err := c.conn.QueryContext(ctx, `SELECT `+strings.Join(selected, ", ")+` FROM `+tableRows(t, "main", compared)+` n
+	JOIN `+tableRows(t, previousSchema, compared)+` p ON `+keyCondition(t, "n", "p")+`
+	WHERE `+changedCondition(compared, "n", "p")+`
+	ORDER BY `+qualified(t.Key, "n")) //nolint:gosec // table and column names are constants
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	const valuesPerColumn = 3
+	key := make([]sql.NullString, len(t.Key))
+	values := make([]any, valuesPerColumn*len(compared))
+	changed := make([]bool, len(compared))
+	dest := make([]any, 0, len(key)+len(values))
+	for i := range key {
+		dest = append(dest, &key[i])
+	}
+	for i := range compared {
+		dest = append(dest, &values[valuesPerColumn*i], &values[valuesPerColumn*i+1], &changed[i])
+	}
+
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+
+		change := Change{Kind: KindChanged, Key: keyValues(t, key)}
+		for i, column := range compared {
+			if changed[i] {
+				change.Fields = append(change.Fields, FieldChange{
+					Column:   column,
+					Previous: value(values[valuesPerColumn*i+1]),
+					Current:  value(values[valuesPerColumn*i]),
+				})
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// Close detaches the previous database and releases the connection.
+func (c *Comparison) Close() error {
+	_, err := c.conn.ExecContext(context.Background(), "DETACH DATABASE "+previousSchema)
+
+	return errors.Join(err, c.conn.Close())
+}
+
+// columns returns the columns of the table in the schema, none if the table doesn't exist
+func (c *Comparison) columns(ctx context.Context, schema, table string) (map[string]bool, error) {
+	rows, err := c.conn.QueryContext(ctx, "SELECT name FROM pragma_table_info(?, ?)", table, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// comparedColumns returns the columns of both versions of the table compared across them, in a stable order
+func comparedColumns(t Table, current, previous map[string]bool) []string {
+	key := make(map[string]bool, len(t.Key))
+	for _, column := range t.Key {
+		key[column] = true
+	}
+
+	var compared []string
+	for column := range current {
+		if previous[column] && !key[column] && !ignoredColumns[column] {
+			compared = append(compared, column)
+		}
+	}
+	sort.Strings(compared)
+
+	return compared
+}
+
+// qualified returns the columns qualified by the alias, separated by commas
+func qualified(columns []string, alias string) string {
+	qualified := make([]string, 0, len(columns))
+	for _, column := range columns {
+		qualified = append(qualified, alias+"."+column)
+	}
+
+	return strings.Join(qualified, ", ")
+}
+
+// keyValues returns the values of the key columns of the table by column
+func keyValues(t Table, key []sql.NullString) map[string]string {
+	values := make(map[string]string, len(t.Key))
+	for i, column := range t.Key {
+		values[column] = key[i].String
+	}
+
+	return values
+}
+
+// value returns the value scanned from a column, the text sqlite returns as bytes is returned as a string
+func value(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+
+	return v
+}
+
+// missingQuery returns the query counting the keys of the table in the from schema missing from the other schema
+func missingQuery(t Table, from, other string, compared []string) string {
+	return `SELECT COUNT(*) FROM ` + tableRows(t, from, compared) + ` n
+	WHERE NOT EXISTS (SELECT 1 FROM ` + tableRows(t, other, compared) + ` p WHERE ` + keyCondition(t, "n", "p") + `)`
+}
+
+// tableRows returns the rows of the table in the schema to select from. The rows of a repeated key are numbered
+// in the order of their compared columns, so that identical rows get the same number in both databases.
+func tableRows(t Table, schema string, compared []string) string {
+	if !t.Repeated {
+		return schema + "." + t.Name
+	}
+
+	order := append(append([]string{}, compared...), "rowid")
+
+	return `(SELECT *, ROW_NUMBER() OVER (PARTITION BY ` + strings.Join(t.Key, ", ") + ` ORDER BY ` + strings.Join(order, ", ") +
+		`) AS ` + ordinalColumn + ` FROM ` + schema + `.` + t.Name + `)`
+}
+
+// keyCondition returns the condition matching the rows of two aliases of the table by key,
+// and by their number among the rows of the key if it is repeated
+func keyCondition(t Table, left, right string) string {
+	conditions := make([]string, 0, len(t.Key)+1)
+	for _, column := range t.Key {
+		conditions = append(conditions, left+"."+column+" = "+right+"."+column)
+	}
+	if t.Repeated {
+		conditions = append(conditions, left+"."+ordinalColumn+" = "+right+"."+ordinalColumn)
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+// changedCondition returns the condition matching the rows of two aliases whose columns differ, null safe
+func changedCondition(columns []string, left, right string) string {
+	conditions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, left+"."+column+" IS NOT "+right+"."+column)
+	}
+
+	return strings.Join(conditions, " OR ")
+}
@@ -0,0 +1,265 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite" // Import the sqlite driver
+)
+
+// createDB creates a database at path made of the statements
+func createDB(t *testing.T, path string, statements ...string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, statement := range statements {
+		_, err = db.Exec(statement)
+		require.NoError(t, err)
+	}
+}
+
+// openDB opens the database at path read-only, the way the tools open the database they compare
+func openDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	uri, err := ReadOnlyURI(path)
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite", uri)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+// bankTable is a bank table of finly with a column the other version lacks, which is left out of the comparison
+const bankTable = `CREATE TABLE bank (id INTEGER PRIMARY KEY, ifsc TEXT, name TEXT, city TEXT, %s TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP)`
+
+func TestComparison(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	previousPath, currentPath := filepath.Join(dir, "previous.db"), filepath.Join(dir, "current db#1.db")
+
+	createDB(t, previousPath,
+		fmt.Sprintf(bankTable, "micr"),
+		`INSERT INTO bank (ifsc, name, city, micr) VALUES
+		('SBIN0000001', 'State Bank of India', 'KOLKATA', '700002001'),
+		('SBIN0000002', 'State Bank of India', NULL, '700002002'),
+		('SBIN0000003', 'State Bank of India', 'HOWRAH', '700002003')`,
+		`CREATE TABLE upi_handle (handle TEXT, psp TEXT)`,
+		`INSERT INTO upi_handle VALUES ('oksbi', 'Google Pay')`,
+	)
+	createDB(t, currentPath,
+		fmt.Sprintf(bankTable, "swift"),
+		`INSERT INTO bank (ifsc, name, city, swift) VALUES
+		('SBIN0000001', 'State Bank of India', 'KOLKATA', 'SBININBB'),
+		('SBIN0000002', 'State Bank of India', 'KOLKATA', NULL),
+		('SBIN0000004', 'State Bank of India', 'DURGAPUR', NULL)`,
+		`CREATE TABLE bank_master (code TEXT, name TEXT)`,
+		`INSERT INTO bank_master VALUES ('SBIN', 'State Bank of India')`,
+	)
+	previous, err := os.ReadFile(previousPath)
+	require.NoError(t, err)
+
+	c, err := Open(ctx, openDB(t, currentPath), previousPath)
+	require.NoError(t, err)
+
+	tables, err := c.Tables(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, FinlyTables, tables)
+
+	counts, err := c.Count(ctx, FinlyTables[0])
+	require.NoError(t, err)
+	assert.Equal(t, Counts{Added: 1, Removed: 1, Changed: 1}, counts)
+
+	changes, err := c.Changes(ctx, FinlyTables[0])
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Kind: KindAdded, Key: map[string]string{"ifsc": "SBIN0000004"}},
+		{Kind: KindRemoved, Key: map[string]string{"ifsc": "SBIN0000003"}},
+		{
+			Kind:   KindChanged,
+			Key:    map[string]string{"ifsc": "SBIN0000002"},
+			Fields: []FieldChange{{Column: "city", Previous: nil, Current: "KOLKATA"}},
+		},
+	}, changes)
+
+	// The tables missing from either database have every row of the other added or removed
+	testCases := []struct {
+		table    Table
+		expected Counts
+		changes  []Change
+	}{
+		{
+			table:    Table{Name: "bank_master", Key: []string{"code"}},
+			expected: Counts{Added: 1},
+			changes:  []Change{{Kind: KindAdded, Key: map[string]string{"code": "SBIN"}}},
+		},
+		{
+			table:    Table{Name: "upi_handle", Key: []string{"handle"}},
+			expected: Counts{Removed: 1},
+			changes:  []Change{{Kind: KindRemoved, Key: map[string]string{"handle": "oksbi"}}},
+		},
+		{
+			table: Table{Name: "ifsc_redirect", Key: []string{"legacy_ifsc"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.table.Name, func(t *testing.T) {
+			counts, err := c.Count(ctx, tc.table)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, counts)
+
+			changes, err := c.Changes(ctx, tc.table)
+			require.NoError(t, err)
+			assert.Equal(t, tc.changes, changes)
+		})
+	}
+
+	// Both databases are opened read-only
+	_, err = c.conn.ExecContext(ctx, "DELETE FROM previous.bank")
+	assert.ErrorContains(t, err, "readonly")
+	_, err = c.conn.ExecContext(ctx, "DELETE FROM main.bank")
+	assert.ErrorContains(t, err, "readonly")
+
+	require.NoError(t, c.Close())
+	current, err := os.ReadFile(previousPath)
+	require.NoError(t, err)
+	assert.Equal(t, previous, current)
+}
+
+func TestTables(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	finlyPath, atlasPath, otherPath := filepath.Join(dir, "finly.db"), filepath.Join(dir, "atlas.db"), filepath.Join(dir, "other.db")
+	createDB(t, finlyPath, fmt.Sprintf(bankTable, "micr"))
+	createDB(t, atlasPath, `CREATE TABLE geo_location (country_code TEXT, postal_code TEXT, place_name TEXT)`)
+	createDB(t, otherPath, `CREATE TABLE other (id INTEGER)`)
+
+	testCases := []struct {
+		expectedError  error
+		name           string
+		currentPath    string
+		previousPath   string
+		expectedOutput []Table
+	}{
+		{name: "atlas", currentPath: atlasPath, previousPath: atlasPath, expectedOutput: AtlasTables},
+		{name: "missing previous database", currentPath: finlyPath, previousPath: filepath.Join(dir, "missing.db"),
+			expectedError: ErrMismatchedDatabase},
+		{name: "databases of different kinds", currentPath: atlasPath, previousPath: finlyPath, expectedError: ErrMismatchedDatabase},
+		{name: "unknown database", currentPath: otherPath, previousPath: finlyPath, expectedError: ErrUnknownDatabase},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := Open(ctx, openDB(t, tc.currentPath), tc.previousPath)
+			require.NoError(t, err)
+			defer c.Close()
+
+			tables, err := c.Tables(ctx)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedOutput, tables)
+			}
+		})
+	}
+}
+
+func TestCountAll(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	currentPath := filepath.Join(dir, "current.db")
+	createDB(t, currentPath,
+		`CREATE TABLE geo_location (id INTEGER PRIMARY KEY, country_code TEXT, postal_code TEXT, place_name TEXT, latitude REAL)`,
+		`INSERT INTO geo_location (country_code, postal_code, place_name, latitude) VALUES
+		('IN', '560095', 'Koramangala VI Bk', 12.9343), ('IN', '560095', 'Koramangala', 12.9352)`,
+	)
+
+	// A missing previous database compares as an empty one
+	changes, err := CountAll(ctx, openDB(t, currentPath), filepath.Join(dir, "missing.db"), AtlasTables)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Counts{"geo_location": {Added: 2}}, changes)
+
+	changes, err = CountAll(ctx, openDB(t, currentPath), currentPath, AtlasTables)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Counts{"geo_location": {}}, changes)
+}
+
+func TestComparisonRepeatedKey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	previousPath, currentPath := filepath.Join(dir, "previous.db"), filepath.Join(dir, "current.db")
+
+	geoLocationTable := `CREATE TABLE geo_location (id INTEGER PRIMARY KEY, country_code TEXT, postal_code TEXT, place_name TEXT,
+		latitude REAL)`
+	// geonames lists Kanakapura twice under the same postal code, the rows are inserted in another order by each import
+	createDB(t, previousPath, geoLocationTable,
+		`INSERT INTO geo_location (country_code, postal_code, place_name, latitude) VALUES
+		('IN', '562117', 'Kanakapura', 12.5462), ('IN', '562117', 'Kanakapura', 12.5501),
+		('IN', '560095', 'Koramangala', 12.9352), ('IN', '560095', 'Koramangala', 12.9352),
+		('IN', '110001', 'Connaught Place', 28.6315), ('IN', '110001', 'Connaught Place', 28.6315)`,
+	)
+	createDB(t, currentPath, geoLocationTable,
+		`INSERT INTO geo_location (country_code, postal_code, place_name, latitude) VALUES
+		('IN', '562117', 'Kanakapura', 12.5501), ('IN', '562117', 'Kanakapura', 12.5462),
+		('IN', '560095', 'Koramangala', 12.9352), ('IN', '560095', 'Koramangala', 12.9360),
+		('IN', '110001', 'Connaught Place', 28.6315)`,
+	)
+	table := AtlasTables[0]
+
+	// Identical databases have no changes despite the repeated keys
+	c, err := Open(ctx, openDB(t, previousPath), previousPath)
+	require.NoError(t, err)
+	counts, err := c.Count(ctx, table)
+	require.NoError(t, err)
+	assert.Equal(t, Counts{}, counts)
+	changes, err := c.Changes(ctx, table)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	require.NoError(t, c.Close())
+
+	c, err = Open(ctx, openDB(t, currentPath), previousPath)
+	require.NoError(t, err)
+	defer c.Close()
+
+	counts, err = c.Count(ctx, table)
+	require.NoError(t, err)
+	assert.Equal(t, Counts{Removed: 1, Changed: 1}, counts)
+
+	changes, err = c.Changes(ctx, table)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Kind: KindRemoved, Key: map[string]string{"country_code": "IN", "postal_code": "110001", "place_name": "Connaught Place"}},
+		{
+			Kind:   KindChanged,
+			Key:    map[string]string{"country_code": "IN", "postal_code": "560095", "place_name": "Koramangala"},
+			Fields: []FieldChange{{Column: "latitude", Previous: 12.9352, Current: 12.936}},
+		},
+	}, changes)
+}
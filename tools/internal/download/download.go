@@ -0,0 +1,398 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package download downloads the upstream files of the tools into a local cache directory.
+//
+// A cached file is revalidated with its ETag or modification time, so that an unchanged file is not
+// downloaded again. A failed download is retried with exponential backoff and resumes where it stopped
+// with a range request, provided the server can tell the partial file is still current.
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds a single attempt of a download, a download cut short resumes on the next attempt
+	DefaultTimeout = 10 * time.Minute
+	// DefaultMaxAttempts is the number of attempts of a download before it fails
+	DefaultMaxAttempts = 5
+	// DefaultBaseDelay is the delay before the first retry, it doubles on every retry
+	DefaultBaseDelay = time.Second
+	// DefaultMaxDelay bounds the delay between two attempts
+	DefaultMaxDelay = 30 * time.Second
+
+	// connectTimeout bounds the time to connect to the server and to receive the response headers
+	connectTimeout = 30 * time.Second
+	// dirPerm is the permission of the cache directory
+	dirPerm = 0o755
+	// filePerm is the permission of the cached files
+	filePerm = 0o644
+	// partSuffix names the file a download is written to until it completes
+	partSuffix = ".part"
+	// metadataSuffix names the file holding the validators of a cached file
+	metadataSuffix = ".json"
+)
+
+// extPattern matches the file extensions kept in the names of the cached files
+var extPattern = regexp.MustCompile(`^\.[A-Za-z0-9]{1,8}$`)
+
+var (
+	ErrNotModified = errors.New("not modified")
+	errResume      = errors.New("partial download can't be resumed")
+)
+
+// StatusError is returned when the server responds with an unexpected status.
+type StatusError struct {
+	// URL is the url of the download
+	URL string
+	// StatusCode is the status code of the response
+	StatusCode int
+	// Status is the status line of the response
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %s for %s", e.Status, e.URL)
+}
+
+// retryable reports whether the server may succeed if asked again
+func (e *StatusError) retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// File is a downloaded file in the cache directory.
+type File struct {
+	// URL is the url the file was downloaded from
+	URL string `json:"url"`
+	// Path is the local path of the file
+	Path string `json:"-"`
+	// ETag is the entity tag of the file, if the server sent one
+	ETag string `json:"etag,omitempty"`
+	// LastModified is the modification time of the file, if the server sent one
+	LastModified time.Time `json:"last_modified,omitempty"`
+	// Cached is set if the cached file was current and not downloaded again
+	Cached bool `json:"-"`
+}
+
+// Client downloads files into a cache directory. The zero value is not usable, use New.
+type Client struct {
+	// HTTP is the client the requests are sent with
+	HTTP *http.Client
+	// CacheDir is the directory the files are downloaded into
+	CacheDir string
+	// MaxAttempts is the number of attempts of a download before it fails
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it doubles on every retry
+	BaseDelay time.Duration
+	// MaxDelay bounds the delay between two attempts
+	MaxDelay time.Duration
+}
+
+// New returns a client downloading into cacheDir with the default timeouts and retries.
+func New(cacheDir string) *Client {
+	dialer := &net.Dialer{Timeout: connectTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = connectTimeout
+
+	return &Client{
+		HTTP:        &http.Client{Timeout: DefaultTimeout, Transport: transport},
+		CacheDir:    cacheDir,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+	}
+}
+
+// DefaultCacheDir returns the cache directory of the named tool in the user cache directory,
+// or in the temporary directory if the user has none.
+func DefaultCacheDir(tool string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "bifrost", tool)
+}
+
+// Fetch downloads the file at url into the cache directory, unless the cached file is current.
+// Failed attempts are retried with exponential backoff, a partial download resumes where it stopped.
+// It returns a *StatusError if the server responds with an unexpected status.
+func (c *Client) Fetch(ctx context.Context, rawURL string) (*File, error) {
+	err := os.MkdirAll(c.CacheDir, dirPerm)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := c.path(rawURL)
+	for attempt := 1; ; attempt++ {
+		var file *File
+		file, err = c.fetch(ctx, rawURL, cachePath)
+		if err == nil {
+			return file, nil
+		}
+
+		var statusErr *StatusError
+		if (errors.As(err, &statusErr) && !statusErr.retryable()) || ctx.Err() != nil || attempt >= c.MaxAttempts {
+			return nil, err
+		}
+
+		delay := c.delay(attempt)
+		slog.WarnContext(ctx, "error downloading file, retrying",
+			slog.Any("err", err), slog.String("url", rawURL), slog.Int("attempt", attempt), slog.Duration("delay", delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// fetch makes a single attempt at downloading the file at rawURL to cachePath
+func (c *Client) fetch(ctx context.Context, rawURL, cachePath string) (*File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// The offsets of a resumed download are those of the file as stored, never of a compressed encoding of it
+	req.Header.Set("Accept-Encoding", "identity")
+
+	// Revalidate the cached file, the server answers not modified if it is current
+	cached, err := readMetadata(cachePath)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		setValidators(req.Header, "If-None-Match", "If-Modified-Since", cached)
+	}
+
+	// Resume the partial download, the server sends the whole file if it changed in the meantime
+	partPath := cachePath + partSuffix
+	partial, offset, err := readPartial(partPath)
+	if err != nil {
+		return nil, err
+	}
+	if partial != nil && offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		setValidators(req.Header, "If-Range", "If-Range", partial)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cached == nil {
+			return nil, fmt.Errorf("%w: %s has no cached file", ErrNotModified, rawURL)
+		}
+		// The cached file is current, a partial download left over is of a file the server no longer serves
+		cached.Path, cached.Cached = cachePath, true
+		return cached, removePartial(partPath)
+	case http.StatusPartialContent:
+		// The partial download is restarted on the next attempt if the range sent is not the one asked for
+		if partial == nil || offset == 0 || !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return nil, errors.Join(fmt.Errorf("%w: unexpected range %q for %s", errResume, resp.Header.Get("Content-Range"), rawURL),
+				removePartial(partPath))
+		}
+	case http.StatusOK:
+		// A new download, or a partial download the server could not resume
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial download no longer matches the file, it is restarted on the next attempt
+		return nil, errors.Join(fmt.Errorf("%w: unexpected status %s for %s", errResume, resp.Status, rawURL), removePartial(partPath))
+	default:
+		return nil, &StatusError{URL: rawURL, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	file := &File{URL: rawURL, Path: cachePath, ETag: resp.Header.Get("ETag")}
+	// A missing or malformed header leaves the modification time zero
+	file.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	err = writePartial(partPath, file, resp.Body, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// The metadata of the previous file is removed before the file is replaced, so that the new file is
+	// never revalidated with it. A file without metadata is downloaded again.
+	err = removeFile(cachePath + metadataSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Rename(partPath, cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeMetadata(cachePath+metadataSuffix, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, removeFile(partPath + metadataSuffix)
+}
+
+// path returns the path of the cached file of rawURL, named after its checksum so that any url maps to a valid name.
+// The extension of the url is kept, the tools tell the format of some files by it.
+func (c *Client) path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	name := hex.EncodeToString(sum[:])
+	if u, err := url.Parse(rawURL); err == nil {
+		if ext := path.Ext(u.Path); extPattern.MatchString(ext) {
+			name += ext
+		}
+	}
+
+	return filepath.Join(c.CacheDir, name)
+}
+
+// delay returns the delay before the attempt following the given one
+func (c *Client) delay(attempt int) time.Duration {
+	delay := c.BaseDelay
+	for i := 1; i < attempt && delay < c.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, c.MaxDelay)
+}
+
+// setValidators sets the validators of the file on the request headers, the etag is preferred over the
+// modification time. A weak etag can't validate a range request, the modification time is used instead.
+func setValidators(header http.Header, etagHeader, timeHeader string, file *File) {
+	switch {
+	case file.ETag != "" && (etagHeader != "If-Range" || !strings.HasPrefix(file.ETag, "W/")):
+		header.Set(etagHeader, file.ETag)
+	case !file.LastModified.IsZero():
+		header.Set(timeHeader, file.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// readMetadata returns the metadata of the cached file at path, or nil if the file or its metadata is missing
+func readMetadata(path string) (*File, error) {
+	data, err := os.ReadFile(path + metadataSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	_, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var file File
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		// A corrupt metadata file only costs a download
+		return nil, nil
+	}
+
+	return &file, nil
+}
+
+// readPartial returns the metadata and the size of the partial download at path, or nil if there is none
+// or it has no validator the server could resume it with
+func readPartial(path string) (*File, int64, error) {
+	file, err := readMetadata(path)
+	if err != nil || file == nil {
+		return nil, 0, err
+	}
+	if (file.ETag == "" || strings.HasPrefix(file.ETag, "W/")) && file.LastModified.IsZero() {
+		return nil, 0, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return file, info.Size(), nil
+}
+
+// writePartial writes the body to the partial download at path from offset, along with the metadata
+// it is resumed with should the body be cut short
+func writePartial(path string, file *File, body io.Reader, offset int64) error {
+	err := writeMetadata(path+metadataSuffix, file)
+	if err != nil {
+		return err
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(path, flag, filePerm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, body)
+
+	return errors.Join(err, f.Close())
+}
+
+// writeMetadata writes the metadata of the file to path
+func writeMetadata(path string, file *File) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, filePerm)
+}
+
+// removePartial removes the partial download at path along with its metadata
+func removePartial(path string) error {
+	return errors.Join(removeFile(path), removeFile(path+metadataSuffix))
+}
+
+// removeFile removes the file at path, if it exists
+func removeFile(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,424 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package download
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	releasePath = "/repos/razorpay/ifsc/releases/latest"
+	assetPath   = "/razorpay/ifsc/releases/download/v2.0.20/IFSC.csv"
+	storagePath = "/assets/IFSC.csv"
+	geonamesZip = "/export/zip/IN.zip"
+)
+
+var modifiedAt = time.Date(2023, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+// upstream is a stand-in for github and geonames, serving their files the way they do and recording the requests
+type upstream struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	// content and etags are the files by path, geonames sends no entity tag
+	content map[string]string
+	etags   map[string]string
+	// failures are the statuses answered to the next requests of a path instead of the file
+	failures map[string][]int
+	// cuts are the number of bytes of a file sent by the next request of a path before the connection drops
+	cuts map[string]int
+}
+
+func newUpstream(t *testing.T) *upstream {
+	u := &upstream{
+		content: map[string]string{
+			releasePath: `{"tag_name": "v2.0.20"}`,
+			storagePath: strings.Repeat("BANK,IFSC,BRANCH\n", 1000),
+			geonamesZip: strings.Repeat("IN\t110001\tConnaught Place\n", 1000),
+		},
+		etags: map[string]string{
+			releasePath: `W/"release-v2.0.20"`,
+			storagePath: `"ifsc-v2.0.20"`,
+		},
+		failures: make(map[string][]int),
+		cuts:     make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	// github redirects the download url of a release asset to its storage
+	mux.HandleFunc(assetPath, func(w http.ResponseWriter, r *http.Request) {
+		u.record(r)
+		http.Redirect(w, r, storagePath, http.StatusFound)
+	})
+	mux.HandleFunc("/", u.serve)
+
+	u.Server = httptest.NewServer(mux)
+	t.Cleanup(u.Close)
+
+	return u
+}
+
+func (u *upstream) record(r *http.Request) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.requests = append(u.requests, r)
+}
+
+func (u *upstream) serve(w http.ResponseWriter, r *http.Request) {
+	u.record(r)
+
+	u.mu.Lock()
+	content, found := u.content[r.URL.Path]
+	etag := u.etags[r.URL.Path]
+	var failure int
+	if statuses := u.failures[r.URL.Path]; len(statuses) > 0 {
+		failure, u.failures[r.URL.Path] = statuses[0], statuses[1:]
+	}
+	cut := u.cuts[r.URL.Path]
+	delete(u.cuts, r.URL.Path)
+	u.mu.Unlock()
+
+	switch {
+	case !found:
+		http.NotFound(w, r)
+		return
+	case failure != 0:
+		w.WriteHeader(failure)
+		return
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Last-Modified", modifiedAt.Format(http.TimeFormat))
+
+	if cut > 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(content[:cut]))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+
+	http.ServeContent(w, r, r.URL.Path, modifiedAt, strings.NewReader(content))
+}
+
+func (u *upstream) set(path, content, etag string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.content[path], u.etags[path] = content, etag
+}
+
+func (u *upstream) fail(path string, statuses ...int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.failures[path] = statuses
+}
+
+func (u *upstream) cut(path string, n int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.cuts[path] = n
+}
+
+// requestsTo returns the requests made to the path
+func (u *upstream) requestsTo(path string) []*http.Request {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var requests []*http.Request
+	for _, r := range u.requests {
+		if r.URL.Path == path {
+			requests = append(requests, r)
+		}
+	}
+
+	return requests
+}
+
+func newTestClient(t *testing.T) *Client {
+	c := New(t.TempDir())
+	c.BaseDelay, c.MaxDelay = time.Millisecond, time.Millisecond
+
+	return c
+}
+
+func readFile(t *testing.T, file *File) string {
+	data, err := os.ReadFile(file.Path)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestFetchRevalidatesCachedFile(t *testing.T) {
+	testCases := []struct {
+		name            string
+		url             string
+		path            string
+		validator       string
+		expectedHeader  string
+		expectedContent string
+	}{
+		{
+			name:            "github release with a weak etag",
+			url:             releasePath,
+			path:            releasePath,
+			validator:       "If-None-Match",
+			expectedHeader:  `W/"release-v2.0.20"`,
+			expectedContent: `{"tag_name": "v2.0.20"}`,
+		},
+		{
+			name:            "github asset behind a redirect",
+			url:             assetPath,
+			path:            storagePath,
+			validator:       "If-None-Match",
+			expectedHeader:  `"ifsc-v2.0.20"`,
+			expectedContent: strings.Repeat("BANK,IFSC,BRANCH\n", 1000),
+		},
+		{
+			name:            "geonames archive without an etag",
+			url:             geonamesZip,
+			path:            geonamesZip,
+			validator:       "If-Modified-Since",
+			expectedHeader:  modifiedAt.Format(http.TimeFormat),
+			expectedContent: strings.Repeat("IN\t110001\tConnaught Place\n", 1000),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := newUpstream(t)
+			c := newTestClient(t)
+
+			file, err := c.Fetch(context.Background(), u.URL+tc.url)
+			require.NoError(t, err)
+			assert.False(t, file.Cached)
+			assert.Equal(t, tc.expectedContent, readFile(t, file))
+			assert.True(t, modifiedAt.Equal(file.LastModified))
+
+			file, err = c.Fetch(context.Background(), u.URL+tc.url)
+			require.NoError(t, err)
+			assert.True(t, file.Cached)
+			assert.Equal(t, tc.expectedContent, readFile(t, file))
+
+			requests := u.requestsTo(tc.path)
+			require.Len(t, requests, 2)
+			assert.Empty(t, requests[0].Header.Get(tc.validator))
+			assert.Equal(t, tc.expectedHeader, requests[1].Header.Get(tc.validator))
+		})
+	}
+}
+
+func TestFetchDownloadsChangedFile(t *testing.T) {
+	u := newUpstream(t)
+	c := newTestClient(t)
+
+	_, err := c.Fetch(context.Background(), u.URL+assetPath)
+	require.NoError(t, err)
+
+	u.set(storagePath, "BANK,IFSC,BRANCH\nState Bank of India,SBIN0000001,Kolkata\n", `"ifsc-v2.0.21"`)
+	file, err := c.Fetch(context.Background(), u.URL+assetPath)
+	require.NoError(t, err)
+	assert.False(t, file.Cached)
+	assert.Equal(t, `"ifsc-v2.0.21"`, file.ETag)
+	assert.Equal(t, "BANK,IFSC,BRANCH\nState Bank of India,SBIN0000001,Kolkata\n", readFile(t, file))
+}
+
+func TestFetchRetries(t *testing.T) {
+	testCases := []struct {
+		name             string
+		failures         []int
+		expectedRequests int
+		expectedStatus   int
+	}{
+		{
+			name:             "server errors are retried",
+			failures:         []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+			expectedRequests: 3,
+		},
+		{
+			name:             "rate limits are retried",
+			failures:         []int{http.StatusTooManyRequests},
+			expectedRequests: 2,
+		},
+		{
+			name:             "attempts are bounded",
+			failures:         []int{500, 500, 500, 500, 500, 500},
+			expectedRequests: DefaultMaxAttempts,
+			expectedStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:             "client errors are not retried",
+			failures:         []int{http.StatusForbidden},
+			expectedRequests: 1,
+			expectedStatus:   http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := newUpstream(t)
+			u.fail(geonamesZip, tc.failures...)
+			c := newTestClient(t)
+
+			file, err := c.Fetch(context.Background(), u.URL+geonamesZip)
+			assert.Len(t, u.requestsTo(geonamesZip), tc.expectedRequests)
+			if tc.expectedStatus != 0 {
+				var statusErr *StatusError
+				require.ErrorAs(t, err, &statusErr)
+				assert.Equal(t, tc.expectedStatus, statusErr.StatusCode)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, strings.Repeat("IN\t110001\tConnaught Place\n", 1000), readFile(t, file))
+		})
+	}
+}
+
+func TestFetchNotFound(t *testing.T) {
+	u := newUpstream(t)
+	c := newTestClient(t)
+
+	_, err := c.Fetch(context.Background(), u.URL+"/razorpay/ifsc/v2.0.20/src/sublet.json")
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Len(t, u.requestsTo("/razorpay/ifsc/v2.0.20/src/sublet.json"), 1)
+}
+
+func TestFetchResumes(t *testing.T) {
+	testCases := []struct {
+		name            string
+		path            string
+		changed         bool
+		expectedIfRange string
+		expectedRange   string
+	}{
+		{
+			name:            "github asset resumes with its etag",
+			path:            storagePath,
+			expectedIfRange: `"ifsc-v2.0.20"`,
+			expectedRange:   "bytes=4000-",
+		},
+		{
+			name:            "geonames archive resumes with its modification time",
+			path:            geonamesZip,
+			expectedIfRange: modifiedAt.Format(http.TimeFormat),
+			expectedRange:   "bytes=4000-",
+		},
+		{
+			name:            "changed file restarts",
+			path:            storagePath,
+			changed:         true,
+			expectedIfRange: `"ifsc-v2.0.20"`,
+			expectedRange:   "bytes=4000-",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := newUpstream(t)
+			u.cut(tc.path, 4000)
+			c := newTestClient(t)
+			c.MaxAttempts = 1
+
+			// The first download is cut short and fails, as the client doesn't retry it
+			_, err := c.Fetch(context.Background(), u.URL+tc.path)
+			require.Error(t, err)
+
+			expectedContent := u.content[tc.path]
+			if tc.changed {
+				expectedContent = strings.Repeat("BANK,IFSC,BRANCH,CITY\n", 1000)
+				u.set(tc.path, expectedContent, `"ifsc-v2.0.21"`)
+			}
+
+			file, err := c.Fetch(context.Background(), u.URL+tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, expectedContent, readFile(t, file))
+
+			requests := u.requestsTo(tc.path)
+			require.Len(t, requests, 2)
+			assert.Equal(t, tc.expectedRange, requests[1].Header.Get("Range"))
+			assert.Equal(t, tc.expectedIfRange, requests[1].Header.Get("If-Range"))
+
+			_, err = os.Stat(file.Path + partSuffix)
+			assert.True(t, errors.Is(err, os.ErrNotExist))
+		})
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+
+		if first {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("IN\t110001\tConnaught Place\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	c := newTestClient(t)
+	c.HTTP.Timeout = 50 * time.Millisecond
+
+	file, err := c.Fetch(context.Background(), server.URL+geonamesZip)
+	require.NoError(t, err)
+	assert.Equal(t, "IN\t110001\tConnaught Place\n", readFile(t, file))
+	assert.Equal(t, 2, calls)
+}
+
+func TestDelay(t *testing.T) {
+	c := &Client{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: time.Second},
+		{attempt: 2, expected: 2 * time.Second},
+		{attempt: 3, expected: 4 * time.Second},
+		{attempt: 4, expected: 5 * time.Second},
+		{attempt: 10, expected: 5 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strconv.Itoa(tc.attempt), func(t *testing.T) {
+			assert.Equal(t, tc.expected, c.delay(tc.attempt))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress draws the progress of the long running imports of the tools on the terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// width is the number of characters of the bar
+	width = 30
+	// redrawInterval is the minimum time between two draws of the bar
+	redrawInterval = 100 * time.Millisecond
+	// megabyte converts bytes to megabytes
+	megabyte = 1 << 20
+	// percent converts a share to a percentage
+	percent = 100
+)
+
+// Bar draws the progress of reading a file of a known size.
+// Nothing is drawn unless the output is a terminal, so that the logs of ci runs stay readable.
+type Bar struct {
+	out     *os.File
+	label   string
+	total   int64
+	current int64
+	started time.Time
+	drawn   time.Time
+	enabled bool
+}
+
+// New returns a bar drawing on out the progress of reading total bytes, e.g. os.Stderr.
+func New(out *os.File, label string, total int64) *Bar {
+	info, err := out.Stat()
+	enabled := err == nil && info.Mode()&os.ModeCharDevice != 0
+
+	now := time.Now()
+
+	return &Bar{out: out, label: label, total: total, started: now, drawn: now, enabled: enabled}
+}
+
+// Reader returns a reader advancing the bar by the bytes read from r.
+// The bar is not safe for concurrent use, the reader must be read from a single goroutine.
+func (b *Bar) Reader(r io.Reader) io.Reader {
+	return &reader{Reader: r, bar: b}
+}
+
+// Add advances the bar by n bytes.
+func (b *Bar) Add(n int64) {
+	b.current += n
+	if b.enabled && time.Since(b.drawn) >= redrawInterval {
+		b.draw()
+	}
+}
+
+// Finish draws the bar a last time and moves to the next line.
+func (b *Bar) Finish() {
+	if !b.enabled {
+		return
+	}
+	b.draw()
+	fmt.Fprintln(b.out)
+}
+
+// draw redraws the bar over the current line
+func (b *Bar) draw() {
+	b.drawn = time.Now()
+
+	share := 1.0
+	if b.total > 0 {
+		share = min(float64(b.current)/float64(b.total), 1)
+	}
+	filled := int(share * width)
+
+	rate := 0.0
+	if elapsed := time.Since(b.started).Seconds(); elapsed > 0 {
+		rate = float64(b.current) / megabyte / elapsed
+	}
+
+	fmt.Fprintf(b.out, "\r%s [%s%s] %5.1f%% %.1f/%.1f MB %.1f MB/s",
+		b.label, strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		percent*share, float64(b.current)/megabyte, float64(b.total)/megabyte, rate)
+}
+
+// reader advances a bar by the bytes read
+type reader struct {
+	io.Reader
+	bar *Bar
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.bar.Add(int64(n))
+
+	return n, err
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openOutput returns a regular file standing in for the output of the bar, it is not a terminal
+func openOutput(t *testing.T) *os.File {
+	t.Helper()
+
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	t.Cleanup(func() { out.Close() })
+
+	return out
+}
+
+// written returns what was written to the output
+func written(t *testing.T, out *os.File) string {
+	t.Helper()
+
+	content, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+
+	return string(content)
+}
+
+func TestBarNotTerminal(t *testing.T) {
+	out := openOutput(t)
+
+	bar := New(out, "IN.zip", 10)
+	assert.False(t, bar.enabled)
+
+	n, err := io.Copy(io.Discard, bar.Reader(strings.NewReader("0123456789")))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), n)
+	assert.Equal(t, int64(10), bar.current)
+
+	bar.Finish()
+	assert.Empty(t, written(t, out))
+}
+
+func TestBarDraw(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		total    int64
+		current  int64
+	}{
+		{
+			name:     "half",
+			total:    4 * megabyte,
+			current:  2 * megabyte,
+			expected: "\rIN.zip [===============               ]  50.0% 2.0/4.0 MB",
+		},
+		{
+			name:     "over total",
+			total:    megabyte,
+			current:  2 * megabyte,
+			expected: "\rIN.zip [==============================] 100.0% 2.0/1.0 MB",
+		},
+		{
+			name:     "unknown total",
+			current:  megabyte,
+			expected: "\rIN.zip [==============================] 100.0% 1.0/0.0 MB",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := openOutput(t)
+
+			// The bar is enabled as if the output were a terminal
+			bar := New(out, "IN.zip", tc.total)
+			bar.enabled = true
+			bar.Add(tc.current)
+			bar.Finish()
+
+			content := written(t, out)
+			assert.True(t, strings.HasPrefix(content, tc.expected), content)
+			assert.True(t, strings.HasSuffix(content, " MB/s\n"), content)
+		})
+	}
+}
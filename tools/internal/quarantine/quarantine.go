@@ -0,0 +1,148 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quarantine keeps the rows rejected by the importers of the tools.
+//
+// An importer validates every row of its source, the rows failing validation are written to the
+// quarantine table along with the reason instead of aborting the import, which only fails once the
+// share of rejected rows goes over a threshold.
+package quarantine
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+const (
+	// DefaultMaxRejectRate is the share of rejected rows an import tolerates by default
+	DefaultMaxRejectRate = 0.01
+	// percent converts a share to a percentage
+	percent = 100
+)
+
+// quarantineTables recreate the quarantine table, it only holds the rows rejected by the latest import
+var quarantineTables = []string{
+	`DROP TABLE IF EXISTS quarantine`,
+	`CREATE TABLE quarantine (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT,
+		line INTEGER,
+		record TEXT,
+		reason TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+var (
+	ErrRejectRate   = errors.New("reject rate over threshold")
+	ErrMalformedRow = errors.New("malformed row")
+)
+
+// Quarantine records the rows rejected by an import and counts the rows seen.
+type Quarantine struct {
+	stmt *sql.Stmt
+
+	// Accepted is the number of rows imported
+	Accepted int64
+	// Rejected is the number of rows written to the quarantine table
+	Rejected int64
+	// Reasons is the number of rejected rows by reason
+	Reasons map[string]int64
+}
+
+// New recreates the quarantine table and returns a Quarantine writing to it.
+func New(ctx context.Context, tx *sql.Tx) (*Quarantine, error) {
+	for _, query := range quarantineTables {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO quarantine (source, line, record, reason) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Quarantine{stmt: stmt, Reasons: make(map[string]int64)}, nil
+}
+
+// Accept counts a row that passed validation.
+func (q *Quarantine) Accept() {
+	q.Accepted++
+}
+
+// AcceptN counts n rows that passed validation.
+func (q *Quarantine) AcceptN(n int64) {
+	q.Accepted += n
+}
+
+// Reject writes the row to the quarantine table along with the reason it was rejected for.
+// The source and line locate the row, the record holds its raw fields.
+// The reasons are counted by message, they should not embed the rejected values, which the record already holds.
+func (q *Quarantine) Reject(ctx context.Context, source string, line int, record []string, reason error) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.stmt.ExecContext(ctx, source, line, string(encoded), reason.Error())
+	if err != nil {
+		return err
+	}
+
+	q.Rejected++
+	q.Reasons[reason.Error()]++
+
+	return nil
+}
+
+// RejectRate returns the share of the rows seen that were rejected.
+func (q *Quarantine) RejectRate() float64 {
+	total := q.Accepted + q.Rejected
+	if total == 0 {
+		return 0
+	}
+
+	return float64(q.Rejected) / float64(total)
+}
+
+// Check returns ErrRejectRate if the share of rejected rows is over maxRate.
+func (q *Quarantine) Check(maxRate float64) error {
+	if rate := q.RejectRate(); rate > maxRate {
+		return fmt.Errorf("%w: rejected %d of %d rows (%.2f%%), the threshold is %.2f%%",
+			ErrRejectRate, q.Rejected, q.Accepted+q.Rejected, percent*rate, percent*maxRate)
+	}
+
+	return nil
+}
+
+// LogSummary logs the number of accepted and rejected rows along with the reasons they were rejected for.
+func (q *Quarantine) LogSummary(ctx context.Context) {
+	slog.InfoContext(ctx, "import summary",
+		slog.Int64("rows", q.Accepted+q.Rejected),
+		slog.Int64("accepted", q.Accepted),
+		slog.Int64("rejected", q.Rejected),
+		slog.String("reject_rate", fmt.Sprintf("%.4f%%", percent*q.RejectRate())),
+		slog.Any("reasons", q.Reasons),
+	)
+}
+
+// Close closes the statement writing to the quarantine table.
+func (q *Quarantine) Close() error {
+	return q.stmt.Close()
+}
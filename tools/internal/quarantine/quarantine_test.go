@@ -0,0 +1,101 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quarantine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite" // Import the sqlite driver
+)
+
+var errInvalidIFSC = errors.New("invalid ifsc")
+
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback() //nolint:errcheck // the rows are only needed until the end of the test
+
+	q, err := New(ctx, tx)
+	require.NoError(t, err)
+	defer q.Close()
+
+	q.AcceptN(97)
+	q.Accept()
+	require.NoError(t, q.Reject(ctx, "IFSC.csv", 3, []string{"SBI", "SBIN"}, errInvalidIFSC))
+	require.NoError(t, q.Reject(ctx, "IFSC.csv", 7, []string{"SBI", "SBIN\"1"}, ErrMalformedRow))
+
+	assert.EqualValues(t, 98, q.Accepted)
+	assert.EqualValues(t, 2, q.Rejected)
+	assert.Equal(t, map[string]int64{"invalid ifsc": 1, "malformed row": 1}, q.Reasons)
+	assert.InDelta(t, 0.02, q.RejectRate(), 1e-9)
+
+	rows, err := tx.QueryContext(ctx, "SELECT source, line, record, reason FROM quarantine ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var quarantined [][]any
+	for rows.Next() {
+		var (
+			source, record, reason string
+			line                   int
+		)
+		require.NoError(t, rows.Scan(&source, &line, &record, &reason))
+		quarantined = append(quarantined, []any{source, line, record, reason})
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][]any{
+		{"IFSC.csv", 3, `["SBI","SBIN"]`, "invalid ifsc"},
+		{"IFSC.csv", 7, `["SBI","SBIN\"1"]`, "malformed row"},
+	}, quarantined)
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		expectedError error
+		name          string
+		accepted      int64
+		rejected      int64
+		maxRate       float64
+	}{
+		{name: "no rows", maxRate: DefaultMaxRejectRate},
+		{name: "nothing rejected", accepted: 100, maxRate: 0},
+		{name: "at the threshold", accepted: 99, rejected: 1, maxRate: DefaultMaxRejectRate},
+		{name: "over the threshold", accepted: 98, rejected: 2, maxRate: DefaultMaxRejectRate, expectedError: ErrRejectRate},
+		{name: "everything rejected", rejected: 5, maxRate: 0.5, expectedError: ErrRejectRate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &Quarantine{Accepted: tc.accepted, Rejected: tc.rejected}
+
+			err := q.Check(tc.maxRate)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rebuild rebuilds the databases of the tools atomically.
+//
+// A rebuild writes to a temporary copy of the database, the database itself is only replaced
+// once the copy is complete and valid, so a failed import never leaves it half written.
+package rebuild
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	_ "github.com/libsql/libsql-client-go/libsql"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// backupSuffix is appended to the path of a database to name the copy of its previous version
+	backupSuffix = ".bak"
+	// previousSchema is the name the database is attached under while its kept tables are copied
+	previousSchema = "previous"
+)
+
+// bulkLoadPragmas trade the durability of the temporary database for the speed of the writes, a failed
+// rebuild discards the temporary database anyway, and Commit syncs it to disk before it replaces the database
+var bulkLoadPragmas = []string{
+	`PRAGMA journal_mode = OFF`,
+	`PRAGMA synchronous = OFF`,
+	`PRAGMA temp_store = MEMORY`,
+	`PRAGMA cache_size = -65536`,
+}
+
+var (
+	ErrEmptyTable = errors.New("empty table")
+	ErrFinished   = errors.New("rebuild already finished")
+)
+
+// Check validates a rebuilt database before it replaces the previous one.
+type Check func(ctx context.Context, db *sql.DB) error
+
+// Build represents a rebuild in progress.
+type Build struct {
+	// DB is the temporary database the rebuild writes to
+	DB *sql.DB
+
+	path    string
+	tmpPath string
+	done    bool
+}
+
+// Start begins the rebuild of the database at path. The temporary database starts as a copy of the
+// database, so that the tables kept across imports survive, and is created next to it so that it
+// can be renamed over it. The database at path is left untouched until Commit.
+func Start(ctx context.Context, path string) (*Build, error) {
+	return start(ctx, path, true)
+}
+
+// StartEmpty begins the rebuild of the database at path like Start, but from an empty database rather than a copy,
+// for the databases whose tables are rebuilt from scratch by every import. The kept tables are copied over from the
+// database along with their rows, the other tables are left for the import to create.
+func StartEmpty(ctx context.Context, path string, keep ...string) (*Build, error) {
+	b, err := start(ctx, path, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.copyTables(ctx, keep)
+	if err != nil {
+		return nil, errors.Join(err, b.Abort())
+	}
+
+	return b, nil
+}
+
+// start creates the temporary database next to the database at path, as a copy of it or empty
+func start(ctx context.Context, path string, copyData bool) (*Build, error) {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Build{path: path, tmpPath: tmpFile.Name()}
+	if copyData {
+		err = copyDatabase(tmpFile, path)
+	}
+	if err != nil {
+		tmpFile.Close()
+		os.Remove(b.tmpPath)
+		return nil, err
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		os.Remove(b.tmpPath)
+		return nil, err
+	}
+
+	b.DB, err = sql.Open("libsql", "file:"+b.tmpPath)
+	if err != nil {
+		os.Remove(b.tmpPath)
+		return nil, err
+	}
+
+	err = b.DB.PingContext(ctx)
+	if err != nil {
+		b.Abort()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Commit validates the temporary database with an integrity check and the given checks, then
+// atomically renames it over the database. The previous database is kept next to it with the
+// .bak suffix as a rollback copy. The temporary database is removed if it fails validation.
+func (b *Build) Commit(ctx context.Context, checks ...Check) error {
+	if b.done {
+		return ErrFinished
+	}
+
+	err := validate(ctx, b.DB, checks)
+	if err != nil {
+		return errors.Join(err, b.Abort())
+	}
+
+	// Close the database before the rename, so that everything written is flushed to the file
+	b.done = true
+	err = b.DB.Close()
+	if err != nil {
+		os.Remove(b.tmpPath)
+		return err
+	}
+
+	// The writes may not be on disk yet if the pragmas of BulkLoad turned off the syncs
+	err = syncFile(b.tmpPath)
+	if err != nil {
+		os.Remove(b.tmpPath)
+		return err
+	}
+
+	err = backup(b.path)
+	if err != nil {
+		os.Remove(b.tmpPath)
+		return err
+	}
+
+	err = os.Rename(b.tmpPath, b.path)
+	if err != nil {
+		os.Remove(b.tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// copyTables copies the tables of the database, along with their rows, to the temporary database.
+// The tables the database lacks are skipped, as is the whole copy if the database doesn't exist yet.
+func (b *Build) copyTables(ctx context.Context, tables []string) error {
+	_, err := os.Stat(b.path)
+	if len(tables) == 0 || errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Attached databases are bound to the connection, the tables are copied on it
+	conn, err := b.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+previousSchema, "file:"+b.path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		var schema string
+		err = conn.QueryRowContext(ctx, "SELECT sql FROM "+previousSchema+".sqlite_master WHERE type = 'table' AND name = ?",
+			table).Scan(&schema)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return errors.Join(err, detach(conn))
+		}
+
+		// The statement creating the table names no schema, it creates the table in the temporary database
+		_, err = conn.ExecContext(ctx, schema)
+		if err != nil {
+			return errors.Join(err, detach(conn))
+		}
+
+		_, err = conn.ExecContext(ctx, "INSERT INTO main."+table+" SELECT * FROM "+previousSchema+"."+table) //nolint:gosec // table names are constants
+		if err != nil {
+			return errors.Join(err, detach(conn))
+		}
+	}
+
+	return detach(conn)
+}
+
+// detach detaches the database attached by copyTables
+func detach(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), "DETACH DATABASE "+previousSchema)
+
+	return err
+}
+
+// BulkLoad tunes the temporary database for loading many rows, turning off the rollback journal and the syncs to disk.
+// The pragmas apply to a single connection, the database is limited to one, so that every query runs on it.
+// A transaction can't be rolled back without the journal, the rebuild must be aborted instead once a write fails.
+func (b *Build) BulkLoad(ctx context.Context) error {
+	b.DB.SetMaxOpenConns(1)
+	for _, pragma := range bulkLoadPragmas {
+		_, err := b.DB.ExecContext(ctx, pragma)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Optimize updates the statistics the query planner chooses the indexes with and compacts the temporary database.
+// It runs outside of any transaction, as VACUUM can't run within one.
+func (b *Build) Optimize(ctx context.Context) error {
+	_, err := b.DB.ExecContext(ctx, "ANALYZE")
+	if err != nil {
+		return err
+	}
+
+	_, err = b.DB.ExecContext(ctx, "VACUUM")
+
+	return err
+}
+
+// Abort discards the temporary database, leaving the database untouched.
+// It does nothing once the rebuild is committed, so it can be deferred right after Start.
+func (b *Build) Abort() error {
+	if b.done {
+		return nil
+	}
+	b.done = true
+
+	return errors.Join(b.DB.Close(), os.Remove(b.tmpPath))
+}
+
+// NotEmpty returns a check failing with ErrEmptyTable if any of the tables has no rows.
+// The table names are trusted, they must never come from user input.
+func NotEmpty(tables ...string) Check {
+	return func(ctx context.Context, db *sql.DB) error {
+		for _, table := range tables {
+			var exists int
+			err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+")").Scan(&exists) //nolint:gosec // table names are constants
+			if err != nil {
+				return err
+			}
+			if exists == 0 {
+				return fmt.Errorf("%w: %s", ErrEmptyTable, table)
+			}
+		}
+
+		return nil
+	}
+}
+
+// validate runs the sqlite integrity check followed by the checks
+func validate(ctx context.Context, db *sql.DB, checks []Check) error {
+	var result string
+	err := db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result)
+	if err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	for _, check := range checks {
+		err = check(ctx, db)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncFile flushes the file at path to disk
+func syncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(f.Sync(), f.Close())
+}
+
+// copyDatabase copies the database at path to dst, a database that doesn't exist yet is left empty
+func copyDatabase(dst io.Writer, path string) error {
+	src, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(dst, src)
+
+	return err
+}
+
+// backup replaces the rollback copy of the database at path with the database, if it exists.
+// The copy is a hard link where possible, the database file is then renamed over without being copied.
+func backup(path string) error {
+	backupPath := path + backupSuffix
+	err := os.Remove(backupPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = os.Link(path, backupPath)
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	// Hard links are not supported everywhere, fall back to a copy
+	dst, err := os.Create(backupPath)
+	if err != nil {
+		return err
+	}
+
+	err = copyDatabase(dst, path)
+	if err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
@@ -0,0 +1,270 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebuild
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createDatabase creates the database at path with a dataset table holding a row and a geo_location table
+func createDatabase(t *testing.T, path string) {
+	t.Helper()
+
+	db, err := sql.Open("libsql", "file:"+path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, query := range []string{
+		`CREATE TABLE dataset (id INTEGER PRIMARY KEY AUTOINCREMENT, version TEXT)`,
+		`INSERT INTO dataset (version) VALUES ('v1')`,
+		`CREATE TABLE geo_location (postal_code TEXT)`,
+		`INSERT INTO geo_location (postal_code) VALUES ('560095')`,
+	} {
+		_, err = db.Exec(query)
+		require.NoError(t, err)
+	}
+}
+
+// tables returns the names of the tables of the database, the internal tables of sqlite aside
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+
+	return names
+}
+
+func TestStart(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		start          func(ctx context.Context, path string) (*Build, error)
+		name           string
+		expectedTables []string
+		existing       bool
+	}{
+		{
+			name:           "copy",
+			start:          Start,
+			existing:       true,
+			expectedTables: []string{"dataset", "geo_location"},
+		},
+		{
+			name: "empty keeping dataset",
+			start: func(ctx context.Context, path string) (*Build, error) {
+				return StartEmpty(ctx, path, "dataset", "missing")
+			},
+			existing:       true,
+			expectedTables: []string{"dataset"},
+		},
+		{
+			name: "empty without database",
+			start: func(ctx context.Context, path string) (*Build, error) {
+				return StartEmpty(ctx, path, "dataset")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			if tc.existing {
+				createDatabase(t, path)
+			}
+
+			b, err := tc.start(ctx, path)
+			require.NoError(t, err)
+			defer b.Abort()
+
+			assert.Equal(t, tc.expectedTables, tables(t, b.DB))
+
+			for _, table := range tc.expectedTables {
+				var count int
+				require.NoError(t, b.DB.QueryRow(`SELECT COUNT(*) FROM `+table).Scan(&count))
+				assert.Equal(t, 1, count, table)
+			}
+
+			// The kept rows keep their ids, the rows inserted by the import follow them
+			if len(tc.expectedTables) > 0 {
+				var id int64
+				require.NoError(t, b.DB.QueryRow(`INSERT INTO dataset (version) VALUES ('v2') RETURNING id`).Scan(&id))
+				assert.Equal(t, int64(2), id)
+			}
+
+			// The previous database is detached, it can be replaced
+			require.NoError(t, b.Commit(ctx))
+		})
+	}
+}
+
+// versions returns the versions recorded in the dataset table of the database at path
+func versions(t *testing.T, path string) []string {
+	t.Helper()
+
+	db, err := sql.Open("libsql", "file:"+path+"?mode=ro")
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT version FROM dataset ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+
+	return names
+}
+
+// files returns the names of the files of the directory
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestCommit(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		expectedError    error
+		name             string
+		checks           []Check
+		expectedVersions []string
+		expectedBackup   []string
+		expectedFiles    []string
+		truncate         bool
+	}{
+		{
+			name:             "valid",
+			checks:           []Check{NotEmpty("dataset", "geo_location")},
+			expectedVersions: []string{"v1", "v2"},
+			expectedBackup:   []string{"v1"},
+			expectedFiles:    []string{"test.db", "test.db.bak"},
+		},
+		{
+			name:             "failed validation",
+			checks:           []Check{NotEmpty("dataset", "geo_location")},
+			truncate:         true,
+			expectedError:    ErrEmptyTable,
+			expectedVersions: []string{"v1"},
+			expectedFiles:    []string{"test.db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "test.db")
+			createDatabase(t, path)
+
+			b, err := Start(ctx, path)
+			require.NoError(t, err)
+			defer b.Abort()
+
+			_, err = b.DB.Exec(`INSERT INTO dataset (version) VALUES ('v2')`)
+			require.NoError(t, err)
+			if tc.truncate {
+				_, err = b.DB.Exec(`DELETE FROM geo_location`)
+				require.NoError(t, err)
+			}
+
+			// The database is left untouched until the commit
+			assert.Equal(t, []string{"v1"}, versions(t, path))
+
+			err = b.Commit(ctx, tc.checks...)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expectedVersions, versions(t, path))
+			if tc.expectedBackup != nil {
+				assert.Equal(t, tc.expectedBackup, versions(t, path+backupSuffix))
+			}
+			// The temporary database is renamed over the database or removed
+			assert.Equal(t, tc.expectedFiles, files(t, dir))
+
+			// The rebuild is finished either way
+			assert.ErrorIs(t, b.Commit(ctx), ErrFinished)
+			assert.NoError(t, b.Abort())
+		})
+	}
+}
+
+func TestAbort(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+	createDatabase(t, path)
+
+	b, err := Start(ctx, path)
+	require.NoError(t, err)
+
+	_, err = b.DB.Exec(`INSERT INTO dataset (version) VALUES ('v2')`)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Abort())
+	assert.Equal(t, []string{"v1"}, versions(t, path))
+	assert.Equal(t, []string{"test.db"}, files(t, dir))
+	assert.ErrorIs(t, b.Commit(ctx), ErrFinished)
+}
+
+func TestCommitReplacesBackup(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	createDatabase(t, path)
+
+	for _, version := range []string{"v2", "v3"} {
+		b, err := Start(ctx, path)
+		require.NoError(t, err)
+
+		_, err = b.DB.Exec(`INSERT INTO dataset (version) VALUES (?)`, version)
+		require.NoError(t, err)
+		require.NoError(t, b.Commit(ctx))
+	}
+
+	assert.Equal(t, []string{"v1", "v2", "v3"}, versions(t, path))
+	// The rollback copy is the database as it was before the last commit
+	assert.Equal(t, []string{"v1", "v2"}, versions(t, path+backupSuffix))
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report writes the machine readable report of an import run of the tools.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/imumesh18/bifrost/tools/internal/diff"
+)
+
+// Status is the outcome of an import run
+type Status string
+
+const (
+	// StatusUpdated means the database was rebuilt from the source
+	StatusUpdated Status = "updated"
+	// StatusUpToDate means the database already holds the latest version of the source
+	StatusUpToDate Status = "up_to_date"
+	// StatusFailed means the import failed and the database was left untouched
+	StatusFailed Status = "failed"
+)
+
+// Source is a file the dataset was built from.
+type Source struct {
+	// URL is where the file was read from
+	URL string `json:"url"`
+	// Checksum is the checksum of the file in the algorithm:hex format
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// Report is the outcome of an import run.
+type Report struct {
+	// Tool is the name of the importer
+	Tool string `json:"tool"`
+	// Status is the outcome of the run
+	Status Status `json:"status"`
+	// Error is the reason the run failed
+	Error string `json:"error,omitempty"`
+	// Version is the upstream version of the imported dataset
+	Version string `json:"version,omitempty"`
+	// PreviousVersion is the upstream version of the dataset the database held before the run
+	PreviousVersion string `json:"previous_version,omitempty"`
+	// Sources are the files the dataset was built from
+	Sources []Source `json:"sources,omitempty"`
+	// RowCounts is the number of rows of every table of the rebuilt database
+	RowCounts map[string]int64 `json:"row_counts,omitempty"`
+	// Rejected is the number of rows quarantined by reason
+	Rejected map[string]int64 `json:"rejected,omitempty"`
+	// Changes is the number of keys added, removed and changed since the previous database by table
+	Changes map[string]diff.Counts `json:"changes,omitempty"`
+	// StartedAt is the time the run started
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt is the time the run finished
+	FinishedAt time.Time `json:"finished_at"`
+	// DurationSeconds is the duration of the run in seconds
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// New returns the report of a run of the tool started at startedAt, the run is failed until marked otherwise.
+func New(tool string, startedAt time.Time) *Report {
+	return &Report{Tool: tool, Status: StatusFailed, StartedAt: startedAt.UTC()}
+}
+
+// Write finishes the report and writes it as json to the file at path, or to the standard output if path is -.
+// The reason of a failed run is taken from err, unless already set.
+func (r *Report) Write(path string, err error) error {
+	if r.Status == StatusFailed && r.Error == "" && err != nil {
+		r.Error = err.Error()
+	}
+	r.FinishedAt = time.Now().UTC()
+	r.DurationSeconds = r.FinishedAt.Sub(r.StartedAt).Seconds()
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, createErr := os.Create(path)
+		if createErr != nil {
+			return createErr
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2023 Umesh Yadav
+//
+// Licensed under the MIT License (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imumesh18/bifrost/tools/internal/diff"
+)
+
+func TestNew(t *testing.T) {
+	startedAt := time.Date(2025, 3, 1, 10, 30, 0, 0, time.FixedZone("IST", 5*60*60+30*60))
+
+	r := New("atlas", startedAt)
+	assert.Equal(t, &Report{Tool: "atlas", Status: StatusFailed, StartedAt: time.Date(2025, 3, 1, 5, 0, 0, 0, time.UTC)}, r)
+}
+
+func TestWrite(t *testing.T) {
+	testCases := []struct {
+		err           error
+		name          string
+		update        func(r *Report)
+		expectedError string
+		expected      Status
+	}{
+		{
+			name:          "failed",
+			err:           errors.New("file is not a database"),
+			expected:      StatusFailed,
+			expectedError: "file is not a database",
+		},
+		{
+			name: "failed with a reason already set",
+			update: func(r *Report) {
+				r.Error = "no assets found"
+			},
+			err:           errors.New("import failed"),
+			expected:      StatusFailed,
+			expectedError: "no assets found",
+		},
+		{
+			name: "updated",
+			update: func(r *Report) {
+				r.Status = StatusUpdated
+			},
+			expected: StatusUpdated,
+		},
+		{
+			name: "up to date despite a late error",
+			update: func(r *Report) {
+				r.Status = StatusUpToDate
+			},
+			err:      errors.New("closing the database"),
+			expected: StatusUpToDate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			startedAt := time.Now().Add(-time.Minute)
+			r := New("finly", startedAt)
+			if tc.update != nil {
+				tc.update(r)
+			}
+
+			path := filepath.Join(t.TempDir(), "report.json")
+			require.NoError(t, r.Write(path, tc.err))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			var written Report
+			require.NoError(t, json.Unmarshal(data, &written))
+			assert.Equal(t, tc.expected, written.Status)
+			assert.Equal(t, tc.expectedError, written.Error)
+			assert.True(t, written.FinishedAt.After(written.StartedAt))
+			assert.InDelta(t, written.FinishedAt.Sub(written.StartedAt).Seconds(), written.DurationSeconds, 1e-6)
+			assert.GreaterOrEqual(t, written.DurationSeconds, time.Minute.Seconds())
+		})
+	}
+}
+
+func TestWriteFormat(t *testing.T) {
+	r := &Report{
+		Tool:            "finly",
+		Status:          StatusUpdated,
+		Version:         "v2.0.21",
+		PreviousVersion: "v2.0.20",
+		Sources:         []Source{{URL: "https://github.com/razorpay/ifsc/releases/download/v2.0.21/IFSC.csv", Checksum: "sha256:2c26b46b"}},
+		RowCounts:       map[string]int64{"bank": 177000},
+		Changes:         map[string]diff.Counts{"bank": {Added: 12, Removed: 3, Changed: 40}},
+		StartedAt:       time.Date(2025, 3, 1, 5, 0, 0, 0, time.UTC),
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, r.Write(path, nil))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var written map[string]any
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, "updated", written["status"])
+	assert.Equal(t, "v2.0.20", written["previous_version"])
+	assert.Equal(t, []any{map[string]any{
+		"url":      "https://github.com/razorpay/ifsc/releases/download/v2.0.21/IFSC.csv",
+		"checksum": "sha256:2c26b46b",
+	}}, written["sources"])
+	assert.Equal(t, map[string]any{"bank": float64(177000)}, written["row_counts"])
+	assert.Equal(t, map[string]any{"bank": map[string]any{"added": float64(12), "removed": float64(3), "changed": float64(40)}}, written["changes"])
+	// The fields left empty are omitted
+	assert.NotContains(t, written, "error")
+	assert.NotContains(t, written, "rejected")
+	// The report is indented for the people reading it
+	assert.Contains(t, string(data), "\n  \"tool\": \"finly\",\n")
+}
+
+func TestWriteUncreatable(t *testing.T) {
+	r := New("atlas", time.Now())
+	err := r.Write(filepath.Join(t.TempDir(), "missing", "report.json"), nil)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}